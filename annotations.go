@@ -0,0 +1,214 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type ControlledValue struct {
+	Id       int64  `json:"id"`
+	Label    string `json:"label"`
+	Ontology string `json:"ontology_uri"`
+	Uri      string `json:"uri"`
+	Blocking bool   `json:"blocking"`
+}
+
+type ControlledTerm struct {
+	Id          int64              `json:"id"`
+	Label       string             `json:"label"`
+	Ontology    string             `json:"ontology_uri"`
+	Uri         string             `json:"uri"`
+	Multivalued bool               `json:"multivalued"`
+	IsValue     bool               `json:"is_value"`
+	Values      []*ControlledValue `json:"values"`
+}
+
+func (t *ControlledTerm) Value(id int64) *ControlledValue {
+	for _, v := range t.Values {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func (t *ControlledTerm) ValueByLabel(label string) *ControlledValue {
+	for _, v := range t.Values {
+		if v.Label == label {
+			return v
+		}
+	}
+	return nil
+}
+
+type Annotation struct {
+	Id                    int64            `json:"id"`
+	Uuid                  string           `json:"uuid"`
+	ResourceType          string           `json:"resource_type"`
+	ResourceId            int64            `json:"resource_id"`
+	ControlledAttributeId int64            `json:"controlled_attribute_id"`
+	ControlledValueId     int64            `json:"controlled_value_id"`
+	UserId                int64            `json:"user_id"`
+	VoteScore             int32            `json:"vote_score"`
+	CreatedAt             time.Time        `json:"created_at"`
+	UpdatedAt             time.Time        `json:"updated_at"`
+	ControlledAttribute   *ControlledTerm  `json:"controlled_attribute"`
+	ControlledValue       *ControlledValue `json:"controlled_value"`
+}
+
+// GetControlledTerms returns the annotation terms and their values. They
+// rarely change, so the first successful response is kept on the client.
+func (c *Client) GetControlledTerms() ([]*ControlledTerm, error) {
+	c.termsLock.Lock()
+	defer c.termsLock.Unlock()
+
+	if c.terms != nil {
+		return c.terms, nil
+	}
+
+	var result []*ControlledTerm
+
+	u := c.buildUrl("/controlled_terms.json")
+	_, err := c.get(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting controlled terms: %v", err)
+	}
+
+	c.terms = result
+
+	return result, nil
+}
+
+func (c *Client) RefreshControlledTerms() ([]*ControlledTerm, error) {
+	c.termsLock.Lock()
+	c.terms = nil
+	c.termsLock.Unlock()
+
+	return c.GetControlledTerms()
+}
+
+func (c *Client) GetControlledTerm(id int64) (*ControlledTerm, error) {
+	terms, err := c.GetControlledTerms()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range terms {
+		if t.Id == id {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("No such controlled term: %d", id)
+}
+
+func (c *Client) GetControlledTermByLabel(label string) (*ControlledTerm, error) {
+	terms, err := c.GetControlledTerms()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range terms {
+		if t.Label == label {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("No such controlled term: '%s'", label)
+}
+
+// DescribeAnnotation fills in the annotation's attribute and value from the
+// cached controlled terms when the server didn't include them.
+func (c *Client) DescribeAnnotation(a *Annotation) error {
+	if a.ControlledAttribute != nil && a.ControlledValue != nil {
+		return nil
+	}
+	term, err := c.GetControlledTerm(a.ControlledAttributeId)
+	if err != nil {
+		return err
+	}
+	value := term.Value(a.ControlledValueId)
+	if value == nil {
+		return fmt.Errorf("No such controlled value: %d", a.ControlledValueId)
+	}
+	a.ControlledAttribute = term
+	a.ControlledValue = value
+	return nil
+}
+
+type AddAnnotationOpt struct {
+	ResourceType          CommentParentType `json:"resource_type"`
+	ResourceId            int64             `json:"resource_id"`
+	ControlledAttributeId int64             `json:"controlled_attribute_id"`
+	ControlledValueId     int64             `json:"controlled_value_id"`
+}
+
+type addAnnotationBody struct {
+	Annotation *AddAnnotationOpt `json:"annotation"`
+}
+
+func (c *Client) AddAnnotation(opt *AddAnnotationOpt) (*Annotation, error) {
+	u := c.buildUrl("/annotations.json")
+
+	if opt.ResourceType == "" {
+		opt.ResourceType = Observation
+	}
+
+	bodyJson, err := json.Marshal(&addAnnotationBody{Annotation: opt})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", u, bytes.NewReader(bodyJson))
+	if err != nil {
+		return nil, err
+	}
+	var result Annotation
+	err = c.execute(req, &result, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.ResourceType == Observation {
+		c.invalidateObservations()
+	}
+
+	return &result, nil
+}
+
+func (c *Client) DeleteAnnotation(uuid string) error {
+	u := c.buildUrl("/annotations/%s.json", uuid)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+func (c *Client) VoteAnnotation(uuid string, agree bool) error {
+	vote := "yes"
+	if !agree {
+		vote = "no"
+	}
+
+	u := c.buildUrl("/votes/vote/annotation/%s.json?vote=%s", uuid, vote)
+	if err := c.executeEmpty("POST", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+func (c *Client) UnvoteAnnotation(uuid string) error {
+	u := c.buildUrl("/votes/unvote/annotation/%s.json", uuid)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
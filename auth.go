@@ -1,8 +1,12 @@
 package gonaturalist
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"crypto/tls"
-	"log"
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -11,17 +15,34 @@ import (
 	"golang.org/x/oauth2"
 )
 
+const (
+	DefaultRootUrl    = "https://www.inaturalist.org"
+	DefaultApiRootUrl = "https://api.inaturalist.org/v1"
+)
+
 type Authenticator struct {
-	rootUrl string
-	config  *oauth2.Config
-	context context.Context
+	rootUrl    string
+	apiRootUrl string
+	config     *oauth2.Config
+	context    context.Context
 }
 
 func NewAuthenticator(clientId string, clientSecret string, redirectUrl string) Authenticator {
-	return NewAuthenticatorAtCustomRoot(clientId, clientSecret, redirectUrl, "https://www.inaturalist.org")
+	return NewAuthenticatorAtCustomRoot(clientId, clientSecret, redirectUrl, DefaultRootUrl)
 }
 
+// NewAuthenticatorAtCustomRoot only knows where the node API is for the
+// default root, for any other root calls that use the node API fail with
+// ErrNoApiRoot unless NewAuthenticatorAtCustomRoots is used instead.
 func NewAuthenticatorAtCustomRoot(clientId string, clientSecret string, redirectUrl string, rootUrl string) Authenticator {
+	apiRootUrl := ""
+	if strings.TrimSuffix(rootUrl, "/") == DefaultRootUrl {
+		apiRootUrl = DefaultApiRootUrl
+	}
+	return NewAuthenticatorAtCustomRoots(clientId, clientSecret, redirectUrl, rootUrl, apiRootUrl)
+}
+
+func NewAuthenticatorAtCustomRoots(clientId string, clientSecret string, redirectUrl string, rootUrl string, apiRootUrl string) Authenticator {
 	endpoint := oauth2.Endpoint{
 		AuthURL:  rootUrl + "/oauth/authorize",
 		TokenURL: rootUrl + "/oauth/token",
@@ -40,30 +61,122 @@ func NewAuthenticatorAtCustomRoot(clientId string, clientSecret string, redirect
 	}
 	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: tr})
 	return Authenticator{
-		rootUrl: rootUrl,
-		config:  cfg,
-		context: ctx,
+		rootUrl:    rootUrl,
+		apiRootUrl: apiRootUrl,
+		config:     cfg,
+		context:    ctx,
 	}
 }
 
-func (a Authenticator) AuthUrl() string {
+func (a Authenticator) AuthUrl() (string, error) {
+	return a.authUrl(url.Values{})
+}
+
+func (a Authenticator) authUrl(extra url.Values) (string, error) {
 	authUrl, err := url.Parse(a.config.Endpoint.AuthURL)
 	if err != nil {
-		log.Fatal("Parse: ", err)
+		return "", fmt.Errorf("Parsing authorize url: %v", err)
 	}
 	parameters := url.Values{}
 	parameters.Add("client_id", a.config.ClientID)
 	parameters.Add("scope", strings.Join(a.config.Scopes, " "))
 	parameters.Add("redirect_uri", a.config.RedirectURL)
 	parameters.Add("response_type", "code")
+	for key, values := range extra {
+		for _, value := range values {
+			parameters.Add(key, value)
+		}
+	}
 	authUrl.RawQuery = parameters.Encode()
-	return authUrl.String()
+	return authUrl.String(), nil
+}
+
+// AuthFlow is an authorization in progress. State must be kept until the
+// redirect comes back, along with CodeVerifier when PKCE is used.
+type AuthFlow struct {
+	Url          string
+	State        string
+	CodeVerifier string
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// BeginAuth generates a state to protect against CSRF and, with pkce, a
+// code verifier so public clients can authorize without a secret.
+func (a Authenticator) BeginAuth(pkce bool) (*AuthFlow, error) {
+	state, err := randomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("Generating state: %v", err)
+	}
+
+	flow := &AuthFlow{
+		State: state,
+	}
+
+	extra := url.Values{}
+	extra.Set("state", state)
+
+	if pkce {
+		verifier, err := randomToken(32)
+		if err != nil {
+			return nil, fmt.Errorf("Generating code verifier: %v", err)
+		}
+		challenge := sha256.Sum256([]byte(verifier))
+		extra.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+		extra.Set("code_challenge_method", "S256")
+		flow.CodeVerifier = verifier
+	}
+
+	flow.Url, err = a.authUrl(extra)
+	if err != nil {
+		return nil, err
+	}
+
+	return flow, nil
+}
+
+func (f *AuthFlow) VerifyState(state string) error {
+	if f.State == "" || subtle.ConstantTimeCompare([]byte(f.State), []byte(state)) != 1 {
+		return fmt.Errorf("Authorization state mismatch")
+	}
+	return nil
+}
+
+// CompleteAuth verifies the state returned with the redirect and exchanges
+// the code, sending the code verifier if the flow used PKCE.
+func (a Authenticator) CompleteAuth(flow *AuthFlow, state string, code string) (*oauth2.Token, error) {
+	if err := flow.VerifyState(state); err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, fmt.Errorf("Authorization code missing")
+	}
+
+	opts := []oauth2.AuthCodeOption{}
+	if flow.CodeVerifier != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", flow.CodeVerifier))
+	}
+
+	return a.config.Exchange(a.context, code, opts...)
 }
 
 func (a Authenticator) Exchange(code string) (*oauth2.Token, error) {
 	return a.config.Exchange(a.context, code)
 }
 
+func (a *Authenticator) transport() http.RoundTripper {
+	if client, ok := a.context.Value(oauth2.HTTPClient).(*http.Client); ok {
+		return client.Transport
+	}
+	return nil
+}
+
 func (a *Authenticator) NewClientWithAccessToken(accessToken string, callbacks Callbacks) *Client {
 	var oauthToken oauth2.Token
 	oauthToken.AccessToken = accessToken
@@ -71,10 +184,59 @@ func (a *Authenticator) NewClientWithAccessToken(accessToken string, callbacks C
 }
 
 func (a *Authenticator) NewClient(token *oauth2.Token, callbacks Callbacks) *Client {
-	client := a.config.Client(a.context, token)
-	return &Client{
-		callbacks: callbacks,
-		rootUrl:   a.rootUrl,
-		http:      client,
+	return a.newClientWithSource(a.config.TokenSource(a.context, token), callbacks)
+}
+
+func (a *Authenticator) newClientWithSource(source oauth2.TokenSource, callbacks Callbacks) *Client {
+	source = oauth2.ReuseTokenSource(nil, source)
+	c := &Client{
+		callbacks:  callbacks,
+		rootUrl:    a.rootUrl,
+		apiRootUrl: a.apiRootUrl,
+		transport:  a.transport(),
+		tokens:     source,
+	}
+	c.Use()
+	return c
+}
+
+// NewClientWithStore saves token to the store and again whenever it's
+// refreshed, so long running services keep their session across restarts.
+func (a *Authenticator) NewClientWithStore(token *oauth2.Token, store TokenStore, callbacks Callbacks) (*Client, error) {
+	if err := store.Save(token); err != nil {
+		return nil, fmt.Errorf("Saving token: %v", err)
+	}
+
+	source := &storingTokenSource{
+		source: a.config.TokenSource(a.context, token),
+		store:  store,
+		last:   token.AccessToken,
+	}
+
+	return a.newClientWithSource(source, callbacks), nil
+}
+
+// NewClientFromStore creates a client using the token saved by a previous
+// run, see NewClientWithStore.
+func (a *Authenticator) NewClientFromStore(store TokenStore, callbacks Callbacks) (*Client, error) {
+	token, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("Loading token: %v", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("No token has been saved")
+	}
+
+	return a.NewClientWithStore(token, store, callbacks)
+}
+
+func (a Authenticator) ExchangeAndStore(code string, store TokenStore) (*oauth2.Token, error) {
+	token, err := a.Exchange(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Save(token); err != nil {
+		return nil, fmt.Errorf("Saving token: %v", err)
 	}
+	return token, nil
 }
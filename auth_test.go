@@ -0,0 +1,133 @@
+package gonaturalist
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBeginAuth(t *testing.T) {
+	a := NewAuthenticatorAtCustomRoots("the-client", "secret", "http://127.0.0.1/callback", "https://inat.example", "https://api.inat.example/v1")
+
+	tests := []struct {
+		pkce bool
+	}{
+		{false},
+		{true},
+	}
+
+	for _, test := range tests {
+		flow, err := a.BeginAuth(test.pkce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := url.Parse(flow.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+
+		if u.Host != "inat.example" || u.Path != "/oauth/authorize" {
+			t.Errorf("unexpected authorize url %s", flow.Url)
+		}
+		if q.Get("client_id") != "the-client" || q.Get("redirect_uri") != "http://127.0.0.1/callback" || q.Get("response_type") != "code" {
+			t.Errorf("unexpected parameters %v", q)
+		}
+		if flow.State == "" || q.Get("state") != flow.State {
+			t.Errorf("expected state %q in %v", flow.State, q)
+		}
+
+		if !test.pkce {
+			if flow.CodeVerifier != "" || q.Get("code_challenge") != "" || q.Get("code_challenge_method") != "" {
+				t.Errorf("expected no PKCE, got %v", q)
+			}
+			continue
+		}
+
+		if len(flow.CodeVerifier) < 43 {
+			t.Errorf("expected a verifier of at least 43 characters, got %q", flow.CodeVerifier)
+		}
+		challenge := sha256.Sum256([]byte(flow.CodeVerifier))
+		if q.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) || q.Get("code_challenge_method") != "S256" {
+			t.Errorf("expected the S256 challenge of the verifier, got %v", q)
+		}
+	}
+
+	first, _ := a.BeginAuth(true)
+	second, _ := a.BeginAuth(true)
+	if first.State == second.State || first.CodeVerifier == second.CodeVerifier {
+		t.Errorf("expected each flow to be random")
+	}
+}
+
+func TestCompleteAuth(t *testing.T) {
+	var verifier string
+	var exchanges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		verifier = r.FormValue("code_verifier")
+		if r.URL.Path != "/oauth/token" || r.FormValue("code") != "the-code" {
+			http.Error(w, "unexpected token request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"the-token","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+
+	a := NewAuthenticatorAtCustomRoots("id", "secret", "http://127.0.0.1/callback", server.URL, server.URL+"/v1")
+
+	tests := []struct {
+		name     string
+		pkce     bool
+		state    func(flow *AuthFlow) string
+		code     string
+		fails    bool
+		exchange bool
+	}{
+		{"state mismatch", true, func(flow *AuthFlow) string { return flow.State + "x" }, "the-code", true, false},
+		{"missing state", true, func(flow *AuthFlow) string { return "" }, "the-code", true, false},
+		{"missing code", true, func(flow *AuthFlow) string { return flow.State }, "", true, false},
+		{"with PKCE", true, func(flow *AuthFlow) string { return flow.State }, "the-code", false, true},
+		{"without PKCE", false, func(flow *AuthFlow) string { return flow.State }, "the-code", false, true},
+	}
+
+	for _, test := range tests {
+		exchanges, verifier = 0, ""
+
+		flow, err := a.BeginAuth(test.pkce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := a.CompleteAuth(flow, test.state(flow), test.code)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if (exchanges > 0) != test.exchange {
+			t.Errorf("%s: expected exchange %v", test.name, test.exchange)
+		}
+		if test.fails {
+			continue
+		}
+
+		if token.AccessToken != "the-token" {
+			t.Errorf("%s: expected the-token, got %s", test.name, token.AccessToken)
+		}
+		if verifier != flow.CodeVerifier {
+			t.Errorf("%s: expected code_verifier %q to be sent, got %q", test.name, flow.CodeVerifier, verifier)
+		}
+	}
+}
+
+func TestVerifyStateWithoutState(t *testing.T) {
+	flow := &AuthFlow{}
+	if err := flow.VerifyState(""); err == nil {
+		t.Errorf("expected an empty state to never verify")
+	}
+}
@@ -0,0 +1,277 @@
+package gonaturalist
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a successful GET response along with what's needed to
+// revalidate it.
+type CachedResponse struct {
+	Url          string      `json:"url"`
+	Body         []byte      `json:"body"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	Expires      time.Time   `json:"expires"`
+}
+
+func (r *CachedResponse) Fresh(now time.Time) bool {
+	return now.Before(r.Expires)
+}
+
+func (r *CachedResponse) CanRevalidate() bool {
+	return r.ETag != "" || r.LastModified != ""
+}
+
+// Cache stores responses keyed by url. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(url string) (*CachedResponse, bool)
+	Set(url string, r *CachedResponse)
+	Delete(url string)
+	DeletePrefix(prefix string)
+}
+
+var cachedHeaders = []string{"X-Total-Entries", "X-Page", "X-Per-Page"}
+
+func newCachedResponse(url string, resp *http.Response, body []byte, ttl time.Duration) *CachedResponse {
+	header := http.Header{}
+	for _, name := range cachedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return &CachedResponse{
+		Url:          url,
+		Body:         body,
+		Header:       header,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Expires:      time.Now().Add(ttl),
+	}
+}
+
+// isCacheable only honors no-store. Rails marks every response private,
+// which is fine as a Client's cache belongs to the one user.
+func isCacheable(resp *http.Response) bool {
+	cc := strings.ToLower(resp.Header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store")
+}
+
+type lruEntry struct {
+	url      string
+	response *CachedResponse
+}
+
+// MemoryCache keeps up to a fixed number of responses, evicting the least
+// recently used.
+type MemoryCache struct {
+	lock     sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(url string) (*CachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).response, true
+}
+
+func (c *MemoryCache) Set(url string, r *CachedResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[url]; ok {
+		e.Value.(*lruEntry).response = r
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[url] = c.order.PushFront(&lruEntry{url: url, response: r})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).url)
+	}
+}
+
+func (c *MemoryCache) Delete(url string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[url]; ok {
+		c.order.Remove(e)
+		delete(c.entries, url)
+	}
+}
+
+func (c *MemoryCache) DeletePrefix(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url, e := range c.entries {
+		if strings.HasPrefix(url, prefix) {
+			c.order.Remove(e)
+			delete(c.entries, url)
+		}
+	}
+}
+
+// DiskCache keeps one JSON file per response in a directory.
+type DiskCache struct {
+	lock sync.Mutex
+	dir  string
+}
+
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{
+		dir: dir,
+	}, nil
+}
+
+func (c *DiskCache) path(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func (c *DiskCache) read(path string) (*CachedResponse, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var r CachedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, false
+	}
+	return &r, true
+}
+
+func (c *DiskCache) Get(url string) (*CachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r, ok := c.read(c.path(url))
+	if !ok || r.Url != url {
+		return nil, false
+	}
+	return r, true
+}
+
+func (c *DiskCache) Set(url string, r *CachedResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	path := c.path(url)
+	temp := path + ".tmp"
+	if err := ioutil.WriteFile(temp, data, 0600); err != nil {
+		return
+	}
+	os.Rename(temp, path)
+}
+
+func (c *DiskCache) Delete(url string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	os.Remove(c.path(url))
+}
+
+// DeletePrefix reads every entry to find matching urls, it's intended for
+// the occasional invalidation.
+func (c *DiskCache) DeletePrefix(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if r, ok := c.read(path); ok && strings.HasPrefix(r.Url, prefix) {
+			os.Remove(path)
+		}
+	}
+}
+
+// SetCache enables caching of GET responses for ttl, after which they're
+// revalidated with If-None-Match or If-Modified-Since when possible.
+func (c *Client) SetCache(cache Cache, ttl time.Duration) {
+	c.cache = cache
+	c.cacheTtl = ttl
+}
+
+func (c *Client) cached(url string) *CachedResponse {
+	if c.cache == nil {
+		return nil
+	}
+	r, ok := c.cache.Get(url)
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+func (c *Client) invalidate(urls ...string) {
+	if c.cache == nil {
+		return
+	}
+	for _, url := range urls {
+		c.cache.Delete(url)
+	}
+}
+
+func (c *Client) invalidatePrefix(prefix string) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.DeletePrefix(prefix)
+}
+
+// invalidateObservations evicts every observation and list of them from
+// both APIs, a change to one can alter which pages it's on and how it looks
+// in them. With a DiskCache this reads every entry.
+func (c *Client) invalidateObservations() {
+	c.invalidatePrefix(c.buildUrl("/observations"))
+	if c.apiRootUrl != "" {
+		c.invalidatePrefix(c.buildApiUrl("/observations"))
+	}
+}
+
+func (c *Client) invalidateProject(id interface{}) {
+	c.invalidate(c.buildUrl("/projects/%v.json", id))
+	c.invalidatePrefix(c.buildUrl("/projects/user/"))
+}
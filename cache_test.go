@@ -0,0 +1,109 @@
+package gonaturalist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+
+	cache.Set("a", &CachedResponse{Url: "a"})
+	cache.Set("b", &CachedResponse{Url: "b"})
+	cache.Get("a")
+	cache.Set("c", &CachedResponse{Url: "c"})
+
+	tests := []struct {
+		url     string
+		present bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	}
+
+	for _, test := range tests {
+		if _, ok := cache.Get(test.url); ok != test.present {
+			t.Errorf("%s: expected present %v", test.url, test.present)
+		}
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		requests     int
+		notModified  int
+	}{
+		{"max-age=0, private, must-revalidate", 2, 1},
+		{"max-age=0, public", 2, 1},
+		{"", 2, 1},
+		{"no-store", 2, 0},
+	}
+
+	for _, test := range tests {
+		requests, notModified := 0, 0
+		c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("ETag", `W/"abc"`)
+			w.Header().Set("Cache-Control", test.cacheControl)
+			if r.Header.Get("If-None-Match") == `W/"abc"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte(`{"id":1,"species_guess":"Raven"}`))
+		}))
+
+		c.SetCache(NewMemoryCache(10), 0)
+
+		for i := 0; i < 2; i++ {
+			o, err := c.GetObservation(1)
+			if err != nil {
+				t.Fatalf("%q: %v", test.cacheControl, err)
+			}
+			if o.Id != 1 || o.SpeciesGuess != "Raven" {
+				t.Errorf("%q: unexpected observation %+v", test.cacheControl, o)
+			}
+		}
+
+		server.Close()
+
+		if requests != test.requests || notModified != test.notModified {
+			t.Errorf("%q: expected %d requests with %d not modified, got %d with %d", test.cacheControl, test.requests, test.notModified, requests, notModified)
+		}
+	}
+}
+
+func TestInvalidateObservations(t *testing.T) {
+	c, server := newTestClient(t, http.NotFoundHandler())
+	defer server.Close()
+
+	cache := NewMemoryCache(10)
+	c.SetCache(cache, 0)
+
+	tests := []struct {
+		url     string
+		evicted bool
+	}{
+		{c.buildUrl("/observations/1.json"), true},
+		{c.buildUrl("/observations.json?page=2"), true},
+		{c.buildUrl("/observations/project/3.json"), true},
+		{c.buildUrl("/observations/jacob.json"), true},
+		{c.buildApiUrl("/observations?id=1,2"), true},
+		{c.buildUrl("/projects/3.json"), false},
+		{c.buildApiUrl("/places/4"), false},
+	}
+
+	for _, test := range tests {
+		cache.Set(test.url, &CachedResponse{Url: test.url})
+	}
+
+	c.invalidateObservations()
+
+	for _, test := range tests {
+		if _, ok := cache.Get(test.url); ok == test.evicted {
+			t.Errorf("%s: expected evicted %v", test.url, test.evicted)
+		}
+	}
+}
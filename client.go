@@ -1,12 +1,19 @@
 package gonaturalist
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 )
 
 const (
@@ -26,9 +33,19 @@ func (c *NoopCallbacks) Completed(method, url string, elapsed time.Duration, err
 type Client struct {
 	callbacks     Callbacks
 	rootUrl       string
+	apiRootUrl    string
 	http          *http.Client
+	transport     http.RoundTripper
+	middleware    []Middleware
+	tokens        oauth2.TokenSource
+	cache         Cache
+	cacheTtl      time.Duration
+	observers     []Observer
+	limiter       *RateLimiter
 	autoRetry     bool
 	retryDuration time.Duration
+	termsLock     sync.Mutex
+	terms         []*ControlledTerm
 }
 
 type PageHeaders struct {
@@ -51,37 +68,61 @@ func shouldRetry(status int) bool {
 }
 
 func (c *Client) execute(req *http.Request, result interface{}, needsStatus ...int) error {
-	started := time.Now()
+	t := c.startRequest(req.Method, req.URL.String())
+	ctx := req.Context()
 
-	for {
+	if req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for {
+
+		if err := c.waitForLimiter(ctx, t); err != nil {
+			t.completed(0, 0, nil, false, err)
+			return err
+		}
 
 		resp, err := c.http.Do(req)
 		if err != nil {
-			c.callbacks.Completed(req.Method, req.URL.String(), time.Since(started), err)
+			t.completed(0, 0, nil, false, err)
 			return err
 		}
 
 		defer resp.Body.Close()
 
 		if c.autoRetry && shouldRetry(resp.StatusCode) {
-			time.Sleep(c.retryDuration)
+			if resp.StatusCode == http.StatusTooManyRequests {
+				t.rateLimited(c.retryDuration)
+			} else {
+				t.retrying(resp.StatusCode, c.retryDuration)
+			}
+			if err := sleep(ctx, c.retryDuration); err != nil {
+				t.completed(resp.StatusCode, 0, nil, false, err)
+				return err
+			}
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					t.completed(resp.StatusCode, 0, nil, false, err)
+					return err
+				}
+			}
 			continue
 		} else if resp.StatusCode != http.StatusOK && isFailure(resp.StatusCode, needsStatus) {
 			err := c.decodeError(resp)
-			c.callbacks.Completed(req.Method, req.URL.String(), time.Since(started), err)
+			t.completed(resp.StatusCode, 0, nil, false, err)
 			return err
 		}
 
+		body := &countingReader{r: resp.Body}
 		if result != nil {
-			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			if err := json.NewDecoder(body).Decode(result); err != nil {
 				err := fmt.Errorf("Decoding body: %v", err)
-				c.callbacks.Completed(req.Method, req.URL.String(), time.Since(started), err)
+				t.completed(resp.StatusCode, body.n, nil, false, err)
 				return err
 			}
 		}
 
-		c.callbacks.Completed(req.Method, req.URL.String(), time.Since(started), err)
+		t.completed(resp.StatusCode, body.n, nil, false, nil)
 
 		break
 	}
@@ -89,46 +130,141 @@ func (c *Client) execute(req *http.Request, result interface{}, needsStatus ...i
 	return nil
 }
 
+func (c *Client) executeEmpty(method, url string, needsStatus ...int) error {
+	empty := make([]byte, 0)
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(empty))
+	if err != nil {
+		return err
+	}
+
+	return c.execute(req, nil, needsStatus...)
+}
+
+func pagingFromHeader(h http.Header) *PageHeaders {
+	total := h.Get("X-Total-Entries")
+	if total == "" {
+		return nil
+	}
+	t, _ := strconv.Atoi(total)
+	p, _ := strconv.Atoi(h.Get("X-Page"))
+	pp, _ := strconv.Atoi(h.Get("X-Per-Page"))
+	return &PageHeaders{
+		TotalEntries: t,
+		Page:         p,
+		PerPage:      pp,
+	}
+}
+
 func (c *Client) get(url string, result interface{}) (paging *PageHeaders, err error) {
-	started := time.Now()
+	return c.getWith(context.Background(), url, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(result)
+	})
+}
+
+// getWith hands the body of the response, cached or not, to decode.
+func (c *Client) getWith(ctx context.Context, url string, decode func(r io.Reader) error) (paging *PageHeaders, err error) {
+	t := c.startRequest("GET", url)
+
+	cached := c.cached(url)
+	if cached != nil && cached.Fresh(t.event.Started) {
+		paging = pagingFromHeader(cached.Header)
+		err := decode(bytes.NewReader(cached.Body))
+		if err != nil {
+			err = fmt.Errorf("Decoding cached body: %v (%s)", err, url)
+		}
+		t.completed(http.StatusOK, int64(len(cached.Body)), paging, true, err)
+		if err != nil {
+			return nil, err
+		}
+		return paging, nil
+	}
+	if cached != nil && !cached.CanRevalidate() {
+		cached = nil
+	}
 
 	for {
-		resp, err := c.http.Get(url)
+		req, err := http.NewRequest("GET", url, nil)
 		if err != nil {
-			c.callbacks.Completed("GET", url, time.Since(started), err)
+			t.completed(0, 0, nil, false, err)
 			return nil, err
 		}
+		req = req.WithContext(ctx)
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
 
-		defer resp.Body.Close()
+		if err := c.waitForLimiter(ctx, t); err != nil {
+			t.completed(0, 0, nil, false, err)
+			return nil, err
+		}
 
-		total := resp.Header["X-Total-Entries"]
-		if len(total) > 0 {
-			perPage := resp.Header["X-Per-Page"]
-			page := resp.Header["X-Page"]
-			t, _ := strconv.Atoi(string(total[0]))
-			p, _ := strconv.Atoi(string(page[0]))
-			pp, _ := strconv.Atoi(string(perPage[0]))
-			paging = &PageHeaders{
-				TotalEntries: t,
-				Page:         p,
-				PerPage:      pp,
-			}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			t.completed(0, 0, nil, false, err)
+			return nil, err
 		}
+
+		defer resp.Body.Close()
+
+		paging = pagingFromHeader(resp.Header)
 		if resp.StatusCode == rateLimitExceededStatusCode && c.autoRetry {
-			time.Sleep(c.retryDuration)
+			t.rateLimited(c.retryDuration)
+			if err := sleep(ctx, c.retryDuration); err != nil {
+				t.completed(resp.StatusCode, 0, paging, false, err)
+				return nil, err
+			}
 			continue
+		} else if resp.StatusCode == http.StatusNotModified && cached != nil {
+			refreshed := *cached
+			refreshed.Expires = time.Now().Add(c.cacheTtl)
+			c.cache.Set(url, &refreshed)
+
+			paging = pagingFromHeader(cached.Header)
+			err = decode(bytes.NewReader(cached.Body))
+			if err != nil {
+				err = fmt.Errorf("Decoding cached body: %v (%s)", err, url)
+				t.completed(resp.StatusCode, int64(len(cached.Body)), paging, true, err)
+				return nil, err
+			}
+
+			t.completed(resp.StatusCode, int64(len(cached.Body)), paging, true, nil)
+
+			return paging, nil
 		} else if resp.StatusCode != http.StatusOK {
 			err := c.decodeError(resp)
-			c.callbacks.Completed("GET", url, time.Since(started), err)
+			t.completed(resp.StatusCode, 0, paging, false, err)
 			return nil, err
 		}
 
-		err = json.NewDecoder(resp.Body).Decode(result)
-		if err != nil {
-			return nil, fmt.Errorf("Decoding body: %v (%s)", err, url)
+		body := &countingReader{r: resp.Body}
+		if c.cache != nil && isCacheable(resp) {
+			data, err := ioutil.ReadAll(body)
+			if err == nil {
+				err = decode(bytes.NewReader(data))
+			}
+			if err != nil {
+				err = fmt.Errorf("Decoding body: %v (%s)", err, url)
+				t.completed(resp.StatusCode, body.n, paging, false, err)
+				return nil, err
+			}
+
+			c.cache.Set(url, newCachedResponse(url, resp, data, c.cacheTtl))
+		} else {
+			err = decode(body)
+			if err != nil {
+				err = fmt.Errorf("Decoding body: %v (%s)", err, url)
+				t.completed(resp.StatusCode, body.n, paging, false, err)
+				return nil, err
+			}
 		}
 
-		c.callbacks.Completed("GET", url, time.Since(started), err)
+		t.completed(resp.StatusCode, body.n, paging, false, nil)
 
 		break
 	}
@@ -136,32 +272,76 @@ func (c *Client) get(url string, result interface{}) (paging *PageHeaders, err e
 	return paging, nil
 }
 
+// Token returns the client's current token, refreshing it if necessary.
+func (c *Client) Token() (*oauth2.Token, error) {
+	return c.tokens.Token()
+}
+
 func (c *Client) buildUrl(f string, args ...interface{}) string {
 	return fmt.Sprintf(c.rootUrl+f, args...)
 }
 
+func (c *Client) buildApiUrl(f string, args ...interface{}) string {
+	return fmt.Sprintf(c.apiRootUrl+f, args...)
+}
+
+type apiResults struct {
+	TotalResults int         `json:"total_results"`
+	Page         int         `json:"page"`
+	PerPage      int         `json:"per_page"`
+	Results      interface{} `json:"results"`
+}
+
+// getApi fetches from the node API, which pages in the body rather than the
+// headers, and decodes the results array into result.
+func (c *Client) getApi(url string, result interface{}) (paging *PageHeaders, err error) {
+	return c.getApiContext(context.Background(), url, result)
+}
+
+var ErrNoApiRoot = errors.New("No node API root configured for this server")
+
+func (c *Client) getApiContext(ctx context.Context, url string, result interface{}) (paging *PageHeaders, err error) {
+	if c.apiRootUrl == "" {
+		return nil, ErrNoApiRoot
+	}
+
+	body := apiResults{
+		Results: result,
+	}
+	_, err = c.getWith(ctx, url, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&body)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PageHeaders{
+		TotalEntries: body.TotalResults,
+		Page:         body.Page,
+		PerPage:      body.PerPage,
+	}, nil
+}
+
 func (c *Client) decodeError(resp *http.Response) error {
 	return fmt.Errorf("%s", resp.Status)
 }
 
+// Deprecated: AcceptableFormats is no longer used, TryParseObservedOn
+// accepts every form ParseFuzzyDateIn does and changing this has no effect.
 var AcceptableFormats = []string{
 	time.RFC3339,
 	"2006/01/02 3:04 PM MST",
 	"2006-01-02",
 }
 
+// TryParseObservedOn keeps any offset or zone given in s, strings without
+// one are taken as UTC. Use ParseFuzzyDateIn to interpret them elsewhere.
 func TryParseObservedOn(s string) (time.Time, error) {
-	str := strings.Trim(s, "\"")
-	if str == "null" || str == "" {
-		return time.Time{}, nil
-	}
-	for _, l := range AcceptableFormats {
-		time, err := time.Parse(l, str)
-		if err == nil {
-			return time, nil
-		}
+	parsed, err := parseFuzzyDate(s, time.UTC, false)
+	if err != nil {
+		return time.Time{}, err
 	}
-	return time.Time{}, fmt.Errorf("Unable to parse time: '%s'", s)
+	return parsed.Time, nil
 }
 
 type NaturalistTime struct {
@@ -182,7 +362,7 @@ func (t *NaturalistTime) MarshalJSON() ([]byte, error) {
 		return []byte("null"), nil
 	}
 
-	return []byte(fmt.Sprintf("\"%s\"", t.Time.Format("2006-01-02 15:04:05"))), nil
+	return []byte(fmt.Sprintf("\"%s\"", t.Time.Format("2006-01-02 15:04:05 -0700"))), nil
 }
 
 func (t *NaturalistTime) IsSet() bool {
@@ -0,0 +1,17 @@
+package gonaturalist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client for a server with both APIs, the node API
+// under /v1.
+func newTestClient(t *testing.T, handler http.Handler) (*Client, *httptest.Server) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	a := NewAuthenticatorAtCustomRoots("id", "secret", "http://127.0.0.1/callback", server.URL, server.URL+"/v1")
+	return a.NewClientWithAccessToken("token", &NoopCallbacks{}), server
+}
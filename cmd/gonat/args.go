@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseWithArgument parses flags around a single positional argument, which
+// may come before or after them.
+func parseWithArgument(fs *flag.FlagSet, args []string, name string) (string, error) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		fs.Parse(args[1:])
+		return args[0], nil
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return "", fmt.Errorf("Missing %s", name)
+	}
+	return fs.Arg(0), nil
+}
+
+func parseWithId(fs *flag.FlagSet, args []string) (int64, error) {
+	value, err := parseWithArgument(fs, args, "id")
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid id: '%s'", value)
+	}
+	return id, nil
+}
+
+func visited(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/conservify/gonaturalist"
+)
+
+func login(e *env, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	pkce := fs.Bool("pkce", false, "use PKCE, for apps without a secret")
+	port := fs.Int("port", 0, "port for the callback listener, random by default")
+	username := fs.String("username", "", "log in with a password instead of a browser")
+	password := fs.String("password", os.Getenv(envPrefix+"PASSWORD"), "password for -username")
+	fs.Parse(args)
+
+	a := e.authenticator()
+	store := e.tokenStore()
+
+	if *username != "" {
+		_, err := a.NewClientWithPasswordAndStore(*username, *password, store, e.callbacks)
+		if err != nil {
+			return err
+		}
+	} else {
+		token, err := a.AuthorizeInteractiveWithOpt(context.Background(), &gonaturalist.InteractiveOpt{
+			Pkce: *pkce,
+			Port: *port,
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.Save(token); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved token to %s\n", e.config.TokenPath)
+
+	return nil
+}
+
+func whoami(e *env, args []string) error {
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	user, err := c.GetCurrentUser()
+	if err != nil {
+		return err
+	}
+
+	return e.out.write(user, []string{"id", "login", "name", "observations"}, [][]string{
+		{strconv.FormatInt(user.Id, 10), user.Login, user.Name, strconv.Itoa(int(user.ObservationsCount))},
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/conservify/gonaturalist"
+)
+
+func addComment(e *env, args []string) error {
+	fs := flag.NewFlagSet("comments add", flag.ExitOnError)
+	observation := fs.Int64("observation", 0, "observation id")
+	body := fs.String("body", "", "comment text")
+	fs.Parse(args)
+
+	if *observation == 0 || *body == "" {
+		return fmt.Errorf("Both -observation and -body are required")
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.AddComment(&gonaturalist.AddCommentOpt{
+		ParentType: gonaturalist.Observation,
+		ParentId:   *observation,
+		Body:       *body,
+	})
+}
+
+func editComment(e *env, args []string) error {
+	fs := flag.NewFlagSet("comments edit", flag.ExitOnError)
+	body := fs.String("body", "", "comment text")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *body == "" {
+		return fmt.Errorf("-body is required")
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.UpdateCommentBody(id, *body)
+}
+
+func deleteComment(e *env, args []string) error {
+	fs := flag.NewFlagSet("comments delete", flag.ExitOnError)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteComment(id)
+}
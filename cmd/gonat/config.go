@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/conservify/gonaturalist"
+)
+
+const envPrefix = "GONAT_"
+
+type config struct {
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RedirectUrl  string `json:"redirect_url"`
+	RootUrl      string `json:"root_url"`
+	ApiRootUrl   string `json:"api_root_url"`
+	AccessToken  string `json:"access_token"`
+	TokenPath    string `json:"token_path"`
+}
+
+func configDirectory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".config", "gonat")
+}
+
+func defaultConfigPath() string {
+	return filepath.Join(configDirectory(), "config.json")
+}
+
+// loadConfig reads the optional config file and then applies environment
+// variables, which take precedence.
+func loadConfig(path string) (*config, error) {
+	c := &config{
+		RootUrl:    gonaturalist.DefaultRootUrl,
+		ApiRootUrl: gonaturalist.DefaultApiRootUrl,
+		TokenPath:  filepath.Join(configDirectory(), "token.json"),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("Reading %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	overrides := map[string]*string{
+		"CLIENT_ID":     &c.ClientId,
+		"CLIENT_SECRET": &c.ClientSecret,
+		"REDIRECT_URL":  &c.RedirectUrl,
+		"ROOT_URL":      &c.RootUrl,
+		"API_ROOT_URL":  &c.ApiRootUrl,
+		"ACCESS_TOKEN":  &c.AccessToken,
+		"TOKEN_PATH":    &c.TokenPath,
+	}
+	for name, value := range overrides {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			*value = v
+		}
+	}
+
+	return c, nil
+}
+
+type env struct {
+	config    *config
+	out       *output
+	callbacks gonaturalist.Callbacks
+}
+
+func (e *env) authenticator() gonaturalist.Authenticator {
+	return gonaturalist.NewAuthenticatorAtCustomRoots(e.config.ClientId, e.config.ClientSecret, e.config.RedirectUrl, e.config.RootUrl, e.config.ApiRootUrl)
+}
+
+func (e *env) tokenStore() gonaturalist.TokenStore {
+	return gonaturalist.NewFileTokenStore(e.config.TokenPath)
+}
+
+func (e *env) client() (*gonaturalist.Client, error) {
+	a := e.authenticator()
+	if e.config.AccessToken != "" {
+		return a.NewClientWithAccessToken(e.config.AccessToken, e.callbacks), nil
+	}
+	c, err := a.NewClientFromStore(e.tokenStore(), e.callbacks)
+	if err != nil {
+		return nil, fmt.Errorf("%v (try gonat login)", err)
+	}
+	return c, nil
+}
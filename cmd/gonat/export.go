@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/conservify/gonaturalist"
+)
+
+var errEnoughPages = errors.New("enough pages")
+
+// exportObservations fetches every page matching the filters.
+func exportObservations(e *env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	filters := newObservationFilters(fs)
+	maxPages := fs.Int("max-pages", 0, "stop after this many pages, zero for all")
+	workers := fs.Int("workers", 4, "pages to fetch concurrently")
+	rate := fs.Float64("rate", 1, "requests per second")
+	fs.Set("per-page", "200")
+	fs.Parse(args)
+
+	opt, err := filters.opt()
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	c.SetRateLimiter(gonaturalist.NewRateLimiter(*rate, *workers))
+
+	all := make([]*gonaturalist.SimpleObservation, 0)
+	pages := 0
+	err = c.FetchObservationPages(context.Background(), opt, *workers, func(page *gonaturalist.ObservationsPage) error {
+		all = append(all, page.Observations...)
+		pages++
+
+		if page.Paging != nil {
+			log.Printf("Exported %d/%d", len(all), page.Paging.TotalEntries)
+		}
+		if *maxPages > 0 && pages >= *maxPages {
+			return errEnoughPages
+		}
+		return nil
+	})
+	if err != nil && err != errEnoughPages {
+		return err
+	}
+
+	return e.out.write(all, observationHeaders, observationRows(all))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conservify/gonaturalist"
+)
+
+// importPhotos groups photos into observations using their EXIF and
+// creates them, or only lists them with -dry-run.
+func importPhotos(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations import", flag.ExitOnError)
+	interval := fs.Duration("interval", gonaturalist.DefaultGroupInterval, "longest gap between photos of one observation")
+	distance := fs.Float64("distance", gonaturalist.DefaultGroupDistanceKm, "furthest apart photos of one observation may be, in km")
+	zone := fs.String("time-zone", "", "zone of photos that don't record one, defaults to local")
+	dryRun := fs.Bool("dry-run", false, "only show the observations that would be created")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("Missing photos")
+	}
+
+	opt := &gonaturalist.GroupPhotosOpt{
+		MaxInterval:   *interval,
+		MaxDistanceKm: *distance,
+	}
+	if *zone != "" {
+		loc, err := gonaturalist.LoadTimeZone(*zone)
+		if err != nil {
+			return err
+		}
+		opt.Location = loc
+	}
+
+	groups, err := gonaturalist.PreparePhotoObservations(fs.Args(), opt)
+	if err != nil {
+		return err
+	}
+
+	var c *gonaturalist.Client
+	if !*dryRun {
+		if c, err = e.client(); err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]string, len(groups))
+	for i, g := range groups {
+		id := ""
+		if c != nil {
+			o, err := c.AddPhotoObservation(g)
+			if err != nil && o != nil {
+				// The observation exists, so running again would
+				// duplicate it. Its remaining photos need adding by hand.
+				return fmt.Errorf("Observation %d was created without all of its photos: %v", o.Id, err)
+			}
+			if err != nil {
+				return err
+			}
+			id = strconv.FormatInt(o.Id, 10)
+			log.Printf("Created %d from %d photo(s)", o.Id, len(g.Photos))
+		}
+
+		observedOn := ""
+		if g.Observation.ObservedOn != nil {
+			observedOn = g.Observation.ObservedOn.Time.Format(time.RFC3339)
+		}
+		names := make([]string, len(g.Photos))
+		for j, p := range g.Photos {
+			names[j] = filepath.Base(p.Path)
+		}
+
+		rows[i] = []string{
+			id,
+			observedOn,
+			formatFloat(g.Observation.Latitude),
+			formatFloat(g.Observation.Longitude),
+			strconv.Itoa(int(g.Observation.PositionalAccuracy)),
+			strings.Join(names, " "),
+		}
+	}
+
+	return e.out.write(groups, []string{"id", "observed_on", "latitude", "longitude", "accuracy", "photos"}, rows)
+}
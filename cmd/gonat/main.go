@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/conservify/gonaturalist"
+)
+
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+type group map[string]*command
+
+var commands = map[string]group{
+	"login": {
+		"": {"login [-pkce] [-username name -password secret]", login},
+	},
+	"whoami": {
+		"": {"whoami", whoami},
+	},
+	"observations": {
+		"list":   {"observations list [filters]", listObservations},
+		"get":    {"observations get <id>", getObservation},
+		"create": {"observations create [fields]", createObservation},
+		"update": {"observations update <id> [fields]", updateObservation},
+		"delete": {"observations delete <id>", deleteObservation},
+		"import": {"observations import [-dry-run] [-interval 5m] [-distance km] <photo>...", importPhotos},
+	},
+	"comments": {
+		"add":    {"comments add -observation <id> -body <text>", addComment},
+		"edit":   {"comments edit <id> -body <text>", editComment},
+		"delete": {"comments delete <id>", deleteComment},
+	},
+	"projects": {
+		"list":  {"projects list [-page n] [-user login]", listProjects},
+		"get":   {"projects get <id|slug>", getProject},
+		"join":  {"projects join <id|slug>", joinProject},
+		"leave": {"projects leave <id|slug>", leaveProject},
+	},
+	"photos": {
+		"download": {"photos download [-dir path] [-sizes original,...] [filters | <id>...]", downloadPhotos},
+	},
+	"places": {
+		"search": {"places search [-page n] <query>", searchPlaces},
+	},
+	"export": {
+		"": {"export [filters]", exportObservations},
+	},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: gonat [-format table|json|csv] [-config path] [-v] <command>\n\n")
+	names := make([]string, 0)
+	for name, g := range commands {
+		for sub := range g {
+			names = append(names, strings.TrimSpace(name+" "+sub))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts := strings.SplitN(name, " ", 2)
+		sub := ""
+		if len(parts) > 1 {
+			sub = parts[1]
+		}
+		fmt.Fprintf(os.Stderr, "  gonat %s\n", commands[parts[0]][sub].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nCredentials are read from the config file and %s* environment variables.\n", envPrefix)
+}
+
+type logCallbacks struct {
+}
+
+func (c *logCallbacks) Completed(method, url string, elapsed time.Duration, err error) {
+	if err != nil {
+		log.Printf("%s %s (%v) %v", method, url, elapsed, err)
+	} else {
+		log.Printf("%s %s (%v)", method, url, elapsed)
+	}
+}
+
+func main() {
+	format := flag.String("format", "table", "output format: table, json or csv")
+	configPath := flag.String("config", defaultConfigPath(), "path to configuration file")
+	verbose := flag.Bool("v", false, "log requests")
+
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	g, ok := commands[args[0]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := g[""]
+	rest := args[1:]
+	if !ok {
+		if len(rest) == 0 || g[rest[0]] == nil {
+			usage()
+			os.Exit(2)
+		}
+		cmd = g[rest[0]]
+		rest = rest[1:]
+	}
+
+	out, err := newOutput(*format, os.Stdout)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	e := &env{
+		config: config,
+		out:    out,
+	}
+	if *verbose {
+		e.callbacks = &logCallbacks{}
+	} else {
+		e.callbacks = &gonaturalist.NoopCallbacks{}
+	}
+
+	if err := cmd.run(e, rest); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conservify/gonaturalist"
+)
+
+type observationFilters struct {
+	fs        *flag.FlagSet
+	page      *int
+	perPage   *int
+	user      *string
+	project   *string
+	place     *int64
+	taxon     *int
+	on        *string
+	from      *string
+	to        *string
+	quality   *string
+	orderBy   *string
+	ascending *bool
+	geo       *bool
+	license   *string
+}
+
+func newObservationFilters(fs *flag.FlagSet) *observationFilters {
+	return &observationFilters{
+		fs:        fs,
+		page:      fs.Int("page", 1, "page number"),
+		perPage:   fs.Int("per-page", 30, "observations per page"),
+		user:      fs.String("user", "", "user id or login"),
+		project:   fs.String("project", "", "project id or slug"),
+		place:     fs.Int64("place", 0, "place id"),
+		taxon:     fs.Int("taxon", 0, "taxon id"),
+		on:        fs.String("on", "", "observed on, e.g. 2019, 2019-05 or 2019-05-01"),
+		from:      fs.String("from", "", "observed on or after date"),
+		to:        fs.String("to", "", "observed on or before date"),
+		quality:   fs.String("quality", "", "quality grade: casual, needs_id or research"),
+		orderBy:   fs.String("order-by", "", "order by field, e.g. created_at or observed_on"),
+		ascending: fs.Bool("asc", false, "ascending order"),
+		geo:       fs.Bool("geo", false, "only georeferenced observations"),
+		license:   fs.String("photo-license", "", "comma separated photo licenses, e.g. cc0,cc-by"),
+	}
+}
+
+func parseDate(s string) (*time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid date: '%s'", s)
+	}
+	return &t, nil
+}
+
+func (f *observationFilters) opt() (*gonaturalist.GetObservationsOpt, error) {
+	set := visited(f.fs)
+	opt := &gonaturalist.GetObservationsOpt{
+		Page:    f.page,
+		PerPage: f.perPage,
+	}
+	if set["user"] {
+		if id, err := strconv.ParseInt(*f.user, 10, 64); err == nil {
+			opt.UserId = &id
+		} else {
+			opt.UserLogin = *f.user
+		}
+	}
+	if set["project"] {
+		if id, err := strconv.ParseInt(*f.project, 10, 64); err == nil {
+			opt.ProjectId = &id
+		} else {
+			opt.ProjectSlug = *f.project
+		}
+	}
+	if set["place"] {
+		opt.PlaceId = f.place
+	}
+	if set["taxon"] {
+		taxon := int32(*f.taxon)
+		opt.TaxonId = &taxon
+	}
+	if set["on"] {
+		on, err := gonaturalist.ParseFuzzyDateIn(*f.on, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		opt.ObservedOn = &on
+	}
+	if set["from"] {
+		from, err := parseDate(*f.from)
+		if err != nil {
+			return nil, err
+		}
+		opt.From = from
+	}
+	if set["to"] {
+		to, err := parseDate(*f.to)
+		if err != nil {
+			return nil, err
+		}
+		opt.To = to
+	}
+	if set["quality"] {
+		quality := gonaturalist.QualityGrade(*f.quality)
+		opt.QualityGrade = &quality
+	}
+	if set["order-by"] {
+		opt.OrderBy = f.orderBy
+	}
+	if set["asc"] {
+		opt.OrderAscending = f.ascending
+	}
+	if set["geo"] {
+		opt.HasGeo = f.geo
+	}
+	if set["photo-license"] {
+		for _, code := range strings.Split(*f.license, ",") {
+			license, err := gonaturalist.ParseLicense(code)
+			if err != nil {
+				return nil, err
+			}
+			opt.PhotoLicense = append(opt.PhotoLicense, license)
+		}
+	}
+	return opt, nil
+}
+
+var observationHeaders = []string{"id", "observed_on", "species_guess", "taxon", "latitude", "longitude", "user", "quality_grade"}
+
+func observationRow(o *gonaturalist.SimpleObservation) []string {
+	taxon := ""
+	if o.Taxon != nil {
+		taxon = o.Taxon.Name
+	}
+	return []string{
+		strconv.FormatInt(o.Id, 10),
+		o.ObservedOn,
+		o.SpeciesGuess,
+		taxon,
+		formatFloat(o.Latitude),
+		formatFloat(o.Longitude),
+		o.UserLogin,
+		string(o.QualityGrade),
+	}
+}
+
+func observationRows(observations []*gonaturalist.SimpleObservation) [][]string {
+	rows := make([][]string, 0, len(observations))
+	for _, o := range observations {
+		rows = append(rows, observationRow(o))
+	}
+	return rows
+}
+
+func listObservations(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations list", flag.ExitOnError)
+	filters := newObservationFilters(fs)
+	fs.Parse(args)
+
+	opt, err := filters.opt()
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	page, err := c.GetObservations(opt)
+	if err != nil {
+		return err
+	}
+
+	return e.out.write(page.Observations, observationHeaders, observationRows(page.Observations))
+}
+
+func getObservation(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations get", flag.ExitOnError)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	o, err := c.GetObservation(id)
+	if err != nil {
+		return err
+	}
+
+	return e.out.write(o, observationHeaders, [][]string{observationRow(o.Simple())})
+}
+
+type observationFields struct {
+	fs          *flag.FlagSet
+	species     *string
+	description *string
+	latitude    *float64
+	longitude   *float64
+	accuracy    *int
+	observed    *string
+	timeZone    *string
+	tags        *string
+	geoPrivacy  *string
+}
+
+func newObservationFields(fs *flag.FlagSet) *observationFields {
+	return &observationFields{
+		fs:          fs,
+		species:     fs.String("species", "", "species guess"),
+		description: fs.String("description", "", "description"),
+		latitude:    fs.Float64("lat", 0, "latitude"),
+		longitude:   fs.Float64("lng", 0, "longitude"),
+		accuracy:    fs.Int("accuracy", 0, "positional accuracy in meters"),
+		observed:    fs.String("observed", "", "when observed, e.g. 2019-05 or \"2019-05-01 22:30\""),
+		timeZone:    fs.String("tz", "", "time zone for -observed, Rails or IANA name"),
+		tags:        fs.String("tags", "", "comma separated tags"),
+		geoPrivacy:  fs.String("geoprivacy", "", "open, obscured or private"),
+	}
+}
+
+func (f *observationFields) observedOn() (*gonaturalist.FuzzyDate, error) {
+	if *f.observed == "" {
+		return nil, nil
+	}
+	observed, err := gonaturalist.ParseFuzzyDate(*f.observed, *f.timeZone)
+	if err != nil {
+		return nil, err
+	}
+	return &observed, nil
+}
+
+func createObservation(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations create", flag.ExitOnError)
+	fields := newObservationFields(fs)
+	fs.Parse(args)
+
+	observedOn, err := fields.observedOn()
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	o, err := c.AddObservation(&gonaturalist.AddObservationOpt{
+		SpeciesGuess:       *fields.species,
+		Description:        *fields.description,
+		Latitude:           *fields.latitude,
+		Longitude:          *fields.longitude,
+		PositionalAccuracy: int32(*fields.accuracy),
+		Tags:               *fields.tags,
+		GeoPrivacy:         gonaturalist.GeoPrivacy(*fields.geoPrivacy),
+		ObservedOn:         observedOn,
+		TimeZone:           *fields.timeZone,
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.out.write(o, observationHeaders, [][]string{observationRow(o)})
+}
+
+func updateObservation(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations update", flag.ExitOnError)
+	fields := newObservationFields(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	observedOn, err := fields.observedOn()
+	if err != nil {
+		return err
+	}
+
+	set := visited(fs)
+	opt := &gonaturalist.UpdateObservationOpt{
+		Id:         id,
+		ObservedOn: observedOn,
+	}
+	if set["species"] {
+		opt.SpeciesGuess = *fields.species
+	}
+	if set["description"] {
+		opt.Description = *fields.description
+	}
+	if set["lat"] {
+		opt.Latitude = *fields.latitude
+	}
+	if set["lng"] {
+		opt.Longitude = *fields.longitude
+	}
+	if set["accuracy"] {
+		opt.PositionalAccuracy = int32(*fields.accuracy)
+	}
+	if set["tags"] {
+		opt.Tags = *fields.tags
+	}
+	if set["geoprivacy"] {
+		opt.GeoPrivacy = gonaturalist.GeoPrivacy(*fields.geoPrivacy)
+	}
+	if set["tz"] {
+		opt.TimeZone = *fields.timeZone
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.UpdateObservation(opt)
+}
+
+func deleteObservation(e *env, args []string) error {
+	fs := flag.NewFlagSet("observations delete", flag.ExitOnError)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteObservation(id)
+}
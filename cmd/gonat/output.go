@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	tableFormat = "table"
+	jsonFormat  = "json"
+	csvFormat   = "csv"
+)
+
+type output struct {
+	format string
+	w      io.Writer
+}
+
+func newOutput(format string, w io.Writer) (*output, error) {
+	switch format {
+	case tableFormat, jsonFormat, csvFormat:
+		return &output{format: format, w: w}, nil
+	}
+	return nil, fmt.Errorf("Unknown format: '%s'", format)
+}
+
+// write renders value as JSON or the rows as a table or CSV.
+func (o *output) write(value interface{}, headers []string, rows [][]string) error {
+	switch o.format {
+	case jsonFormat:
+		e := json.NewEncoder(o.w)
+		e.SetIndent("", "  ")
+		return e.Encode(value)
+	case csvFormat:
+		w := csv.NewWriter(o.w)
+		if err := w.Write(headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(headers, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.Replace(cell, "\n", " ", -1)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
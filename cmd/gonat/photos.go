@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/conservify/gonaturalist"
+)
+
+// downloadPhotos archives the photos of the observations given by id, or
+// of every observation matching the filters.
+func downloadPhotos(e *env, args []string) error {
+	fs := flag.NewFlagSet("photos download", flag.ExitOnError)
+	filters := newObservationFilters(fs)
+	dir := fs.String("dir", "photos", "archive directory")
+	sizes := fs.String("sizes", "original", "comma separated sizes: square, thumb, small, medium, large, original")
+	workers := fs.Int("workers", 4, "concurrent downloads")
+	fs.Set("per-page", "200")
+	fs.Parse(args)
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	c.SetRateLimiter(gonaturalist.NewRateLimiter(1, *workers))
+
+	ctx := context.Background()
+
+	ids := make([]int64, 0)
+	for _, arg := range fs.Args() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid id: '%s'", arg)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		opt, err := filters.opt()
+		if err != nil {
+			return err
+		}
+		all, err := c.GetAllObservations(ctx, opt, *workers)
+		if err != nil {
+			return err
+		}
+		for _, o := range all {
+			ids = append(ids, o.Id)
+		}
+	}
+
+	found, err := c.GetObservationsByIds(ctx, ids, *workers)
+	if err != nil {
+		return err
+	}
+	for _, id := range found.Missing {
+		log.Printf("Observation %d is missing", id)
+	}
+
+	observations := make([]*gonaturalist.FullObservation, 0, len(found.Observations))
+	for _, id := range ids {
+		if o, ok := found.Observations[id]; ok {
+			observations = append(observations, o)
+		}
+	}
+
+	opt := &gonaturalist.DownloadPhotosOpt{
+		Directory: *dir,
+		Workers:   *workers,
+	}
+	for _, size := range strings.Split(*sizes, ",") {
+		opt.Sizes = append(opt.Sizes, gonaturalist.PhotoSize(strings.TrimSpace(size)))
+	}
+
+	downloaded, err := c.DownloadPhotos(ctx, observations, opt)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(downloaded))
+	for i, d := range downloaded {
+		status := "downloaded"
+		if d.Skipped {
+			status = "skipped"
+		}
+		rows[i] = []string{
+			strconv.FormatInt(d.ObservationId, 10),
+			strconv.FormatInt(d.PhotoId, 10),
+			string(d.Size),
+			status,
+			d.Path,
+			d.License.String(),
+		}
+	}
+
+	return e.out.write(downloaded, []string{"observation", "photo", "size", "status", "path", "license"}, rows)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+)
+
+func searchPlaces(e *env, args []string) error {
+	fs := flag.NewFlagSet("places search", flag.ExitOnError)
+	page := fs.Int("page", 1, "page number")
+	query, err := parseWithArgument(fs, args, "query")
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	places, err := c.SearchPlaces(query, page)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(places.Places))
+	for _, p := range places.Places {
+		rows = append(rows, []string{
+			strconv.FormatInt(p.Id, 10),
+			p.DisplayName,
+			p.PlaceTypeName,
+			formatFloat(p.Latitude),
+			formatFloat(p.Longitude),
+		})
+	}
+
+	return e.out.write(places.Places, []string{"id", "name", "type", "latitude", "longitude"}, rows)
+}
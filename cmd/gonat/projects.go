@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/conservify/gonaturalist"
+)
+
+var projectHeaders = []string{"id", "title", "created_at"}
+
+func projectRow(p *gonaturalist.SimpleProject) []string {
+	return []string{
+		strconv.FormatInt(p.Id, 10),
+		p.Title,
+		p.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+func listProjects(e *env, args []string) error {
+	fs := flag.NewFlagSet("projects list", flag.ExitOnError)
+	page := fs.Int("page", 1, "page number")
+	user := fs.String("user", "", "only projects joined by this login")
+	fs.Parse(args)
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	var projects *gonaturalist.ProjectsPage
+	if *user != "" {
+		projects, err = c.GetProjectsByLogin(*user)
+	} else {
+		projects, err = c.GetProjects(&gonaturalist.GetProjectsOpt{Page: page})
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(projects.Projects))
+	for i := range projects.Projects {
+		rows = append(rows, projectRow(&projects.Projects[i]))
+	}
+
+	return e.out.write(projects.Projects, projectHeaders, rows)
+}
+
+func getProject(e *env, args []string) error {
+	fs := flag.NewFlagSet("projects get", flag.ExitOnError)
+	id, err := parseWithArgument(fs, args, "project id or slug")
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	project, err := c.GetProject(id)
+	if err != nil {
+		return err
+	}
+
+	headers := append(projectHeaders, "type", "observations")
+	row := append(projectRow(&project.SimpleProject), project.ProjectType, strconv.Itoa(project.ProjectObservationsCount))
+
+	return e.out.write(project, headers, [][]string{row})
+}
+
+func joinProject(e *env, args []string) error {
+	fs := flag.NewFlagSet("projects join", flag.ExitOnError)
+	id, err := parseWithArgument(fs, args, "project id or slug")
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.JoinProject(id)
+}
+
+func leaveProject(e *env, args []string) error {
+	fs := flag.NewFlagSet("projects leave", flag.ExitOnError)
+	id, err := parseWithArgument(fs, args, "project id or slug")
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	return c.LeaveProject(id)
+}
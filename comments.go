@@ -58,6 +58,10 @@ func (c *Client) AddComment(opt *AddCommentOpt) error {
 		return err
 	}
 
+	if opt.ParentType == Observation {
+		c.invalidateObservations()
+	}
+
 	return nil
 }
 
@@ -87,6 +91,9 @@ func (c *Client) UpdateComment(opt *UpdateCommentOpt) error {
 		return err
 	}
 
+	// Comments are cached as part of their observation, which isn't known.
+	c.invalidateObservations()
+
 	return nil
 }
 
@@ -104,5 +111,7 @@ func (c *Client) DeleteComment(id int64) error {
 		return err
 	}
 
+	c.invalidateObservations()
+
 	return nil
 }
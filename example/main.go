@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/Conservify/gonaturalist"
@@ -13,40 +13,20 @@ var authenticator = gonaturalist.NewAuthenticatorAtCustomRoot(applicationId, sec
 
 // var authenticator = gonaturalist.NewAuthenticatorAtCustomRoot(applicationId, secret, redirectUrl, "https://www.inaturalist.org")
 
-func handleNaturalistLogin(w http.ResponseWriter, r *http.Request) {
-	url := authenticator.AuthUrl()
-
-	log.Printf("Redirecting: %s", url)
-
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
-
-func completeAuth(w http.ResponseWriter, r *http.Request) {
-	code := r.FormValue("code")
-
-	token, err := authenticator.Exchange(code)
-	if err != nil {
-		log.Fatalf("Error: %v", err)
-	}
-
-	log.Printf("Token: %+v", token)
-	log.Printf("AccessToken: %s", token.AccessToken)
-	log.Printf("RefreshToken: %s", token.RefreshToken)
-}
-
 func main() {
 	if accessToken == "" {
-		log.Printf("No access token, staring web server.")
+		log.Printf("No access token, authorizing.")
 
-		http.HandleFunc("/login", handleNaturalistLogin)
-		http.HandleFunc("/callback", completeAuth)
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			log.Println("Ignoring:", r.URL.String())
-		})
+		token, err := authenticator.AuthorizeInteractive(context.Background())
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
 
-		log.Printf("Open http://127.0.0.1:8000/login")
+		log.Printf("Token: %+v", token)
+		log.Printf("AccessToken: %s", token.AccessToken)
+		log.Printf("RefreshToken: %s", token.RefreshToken)
 
-		http.ListenAndServe(":8000", nil)
+		accessToken = token.AccessToken
 	}
 
 	c := authenticator.NewClientWithAccessToken(accessToken, &gonaturalist.NoopCallbacks{})
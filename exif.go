@@ -0,0 +1,396 @@
+package gonaturalist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoExif  = errors.New("No EXIF metadata")
+	ErrNotJpeg = errors.New("Not a JPEG")
+)
+
+// PhotoMetadata is what's read from a photo's EXIF. TakenAt is zero when
+// the camera didn't record it, and HasOffset is false when the offset
+// couldn't be found or derived, in which case TakenAt is in the location
+// given to ReadPhotoMetadata.
+type PhotoMetadata struct {
+	Path      string
+	TakenAt   time.Time
+	HasOffset bool
+	Location  *Location
+	// Accuracy is the horizontal error of Location in meters, zero when
+	// unknown.
+	Accuracy float64
+	Altitude *float64
+	Make     string
+	Model    string
+}
+
+const (
+	tiffByte      = 1
+	tiffAscii     = 2
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffRational  = 5
+	tiffUndefined = 7
+	tiffSlong     = 9
+	tiffSrational = 10
+)
+
+var tiffTypeSizes = map[uint16]uint32{
+	tiffByte:      1,
+	tiffAscii:     1,
+	tiffShort:     2,
+	tiffLong:      4,
+	tiffRational:  8,
+	tiffUndefined: 1,
+	tiffSlong:     4,
+	tiffSrational: 8,
+}
+
+const (
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagDateTime           = 0x0132
+	tagExifIfd            = 0x8769
+	tagGpsIfd             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTime         = 0x9010
+	tagOffsetTimeOriginal = 0x9011
+
+	tagGpsLatitudeRef       = 0x0001
+	tagGpsLatitude          = 0x0002
+	tagGpsLongitudeRef      = 0x0003
+	tagGpsLongitude         = 0x0004
+	tagGpsAltitudeRef       = 0x0005
+	tagGpsAltitude          = 0x0006
+	tagGpsTimeStamp         = 0x0007
+	tagGpsDateStamp         = 0x001d
+	tagGpsHPositioningError = 0x001f
+)
+
+type tiffEntry struct {
+	kind  uint16
+	count uint32
+	data  []byte
+}
+
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (t *tiff) ifd(offset uint32) (map[uint16]*tiffEntry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("IFD offset out of range")
+	}
+
+	n := uint32(t.order.Uint16(t.data[offset:]))
+	if uint64(offset)+2+uint64(n)*12 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("IFD truncated")
+	}
+
+	entries := make(map[uint16]*tiffEntry)
+	for i := uint32(0); i < n; i++ {
+		e := t.data[offset+2+i*12:]
+		tag := t.order.Uint16(e[0:])
+		kind := t.order.Uint16(e[2:])
+		count := t.order.Uint32(e[4:])
+
+		size, ok := tiffTypeSizes[kind]
+		if !ok {
+			continue
+		}
+		length := uint64(size) * uint64(count)
+
+		// Values of four bytes or less are stored in the entry itself,
+		// otherwise the entry holds their offset.
+		var value []byte
+		if length <= 4 {
+			value = e[8 : 8+length]
+		} else {
+			at := uint64(t.order.Uint32(e[8:]))
+			if at+length > uint64(len(t.data)) {
+				continue
+			}
+			value = t.data[at : at+length]
+		}
+
+		entries[tag] = &tiffEntry{kind: kind, count: count, data: value}
+	}
+
+	return entries, nil
+}
+
+func (t *tiff) ascii(e *tiffEntry) string {
+	if e == nil || e.kind != tiffAscii {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.data), "\x00"))
+}
+
+func (t *tiff) long(e *tiffEntry) (uint32, bool) {
+	if e == nil || e.count < 1 {
+		return 0, false
+	}
+	switch e.kind {
+	case tiffLong:
+		return t.order.Uint32(e.data), true
+	case tiffShort:
+		return uint32(t.order.Uint16(e.data)), true
+	}
+	return 0, false
+}
+
+func (t *tiff) rationals(e *tiffEntry) []float64 {
+	if e == nil || (e.kind != tiffRational && e.kind != tiffSrational) {
+		return nil
+	}
+	values := make([]float64, e.count)
+	for i := range values {
+		var n, d float64
+		if e.kind == tiffRational {
+			n = float64(t.order.Uint32(e.data[i*8:]))
+			d = float64(t.order.Uint32(e.data[i*8+4:]))
+		} else {
+			n = float64(int32(t.order.Uint32(e.data[i*8:])))
+			d = float64(int32(t.order.Uint32(e.data[i*8+4:])))
+		}
+		if d == 0 {
+			values[i] = math.NaN()
+		} else {
+			values[i] = n / d
+		}
+	}
+	return values
+}
+
+func (t *tiff) degrees(value *tiffEntry, ref *tiffEntry, negative string) (float64, bool) {
+	dms := t.rationals(value)
+	if len(dms) != 3 {
+		return 0, false
+	}
+	degrees := dms[0] + dms[1]/60 + dms[2]/3600
+	if math.IsNaN(degrees) {
+		return 0, false
+	}
+	if strings.EqualFold(t.ascii(ref), negative) {
+		degrees = -degrees
+	}
+	return degrees, true
+}
+
+// findExif returns the TIFF structure from a JPEG's APP1 segment.
+func findExif(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrNotJpeg
+	} else if err != nil {
+		return nil, err
+	}
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return nil, ErrNotJpeg
+	}
+
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(br, marker[:]); err != nil {
+			return nil, err
+		}
+		if marker[0] != 0xff {
+			return nil, fmt.Errorf("Invalid JPEG marker")
+		}
+		// Markers may be padded with any number of 0xff.
+		for marker[1] == 0xff {
+			b, err := br.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			marker[1] = b
+		}
+		// Start of scan, the image data follows and there's no more
+		// metadata.
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return nil, ErrNoExif
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return nil, fmt.Errorf("Invalid JPEG segment")
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(br, segment); err != nil {
+			return nil, err
+		}
+
+		if marker[1] == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+func parseTiff(data []byte) (*tiff, uint32, error) {
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("TIFF header truncated")
+	}
+
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("Invalid TIFF byte order")
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, 0, fmt.Errorf("Invalid TIFF header")
+	}
+
+	return t, t.order.Uint32(data[4:]), nil
+}
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+func parseExifOffset(s string) (*time.Location, bool) {
+	t, err := time.Parse("-07:00", s)
+	if err != nil {
+		return nil, false
+	}
+	_, offset := t.Zone()
+	return time.FixedZone("", offset), true
+}
+
+// offsetFromGps derives the offset the camera's clock was in from the GPS
+// time, which is always UTC, rounded to the nearest quarter hour.
+func offsetFromGps(local time.Time, gps time.Time) (*time.Location, bool) {
+	difference := local.Sub(gps)
+	quarters := math.Round(difference.Minutes() / 15)
+	if math.Abs(quarters) > 14*4 {
+		return nil, false
+	}
+	return time.FixedZone("", int(quarters)*15*60), true
+}
+
+// ReadPhotoMetadata reads the EXIF of a JPEG, returning ErrNoExif if it
+// has none and ErrNotJpeg for other formats. Times without an offset,
+// either recorded or derived from the GPS time, are read in loc.
+func ReadPhotoMetadata(r io.Reader, loc *time.Location) (*PhotoMetadata, error) {
+	data, err := findExif(r)
+	if err != nil {
+		return nil, err
+	}
+
+	t, offset, err := parseTiff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ifd0, err := t.ifd(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	md := &PhotoMetadata{
+		Make:  t.ascii(ifd0[tagMake]),
+		Model: t.ascii(ifd0[tagModel]),
+	}
+
+	taken := t.ascii(ifd0[tagDateTime])
+	zone := ""
+	if at, ok := t.long(ifd0[tagExifIfd]); ok {
+		if exif, err := t.ifd(at); err == nil {
+			if original := t.ascii(exif[tagDateTimeOriginal]); original != "" {
+				taken = original
+				zone = t.ascii(exif[tagOffsetTimeOriginal])
+			} else {
+				zone = t.ascii(exif[tagOffsetTime])
+			}
+		}
+	}
+
+	var gpsTime *time.Time
+	if at, ok := t.long(ifd0[tagGpsIfd]); ok {
+		if gps, err := t.ifd(at); err == nil {
+			lat, latOk := t.degrees(gps[tagGpsLatitude], gps[tagGpsLatitudeRef], "S")
+			lng, lngOk := t.degrees(gps[tagGpsLongitude], gps[tagGpsLongitudeRef], "W")
+			if latOk && lngOk && !(lat == 0 && lng == 0) {
+				md.Location = &Location{Latitude: lat, Longitude: lng}
+			}
+
+			if horizontal := t.rationals(gps[tagGpsHPositioningError]); len(horizontal) == 1 && !math.IsNaN(horizontal[0]) {
+				md.Accuracy = horizontal[0]
+			}
+
+			if altitude := t.rationals(gps[tagGpsAltitude]); len(altitude) == 1 && !math.IsNaN(altitude[0]) {
+				value := altitude[0]
+				if ref := gps[tagGpsAltitudeRef]; ref != nil && len(ref.data) > 0 && ref.data[0] == 1 {
+					value = -value
+				}
+				md.Altitude = &value
+			}
+
+			date := t.ascii(gps[tagGpsDateStamp])
+			hms := t.rationals(gps[tagGpsTimeStamp])
+			if date != "" && len(hms) == 3 {
+				if day, err := time.Parse("2006:01:02", date); err == nil {
+					at := day.Add(time.Duration(hms[0]*float64(time.Hour) + hms[1]*float64(time.Minute) + hms[2]*float64(time.Second)))
+					gpsTime = &at
+				}
+			}
+		}
+	}
+
+	if taken != "" {
+		if local, err := time.Parse(exifTimeLayout, taken); err == nil {
+			if fixed, ok := parseExifOffset(zone); ok {
+				md.TakenAt = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, fixed)
+				md.HasOffset = true
+			} else if gpsTime != nil {
+				if fixed, ok := offsetFromGps(local, *gpsTime); ok {
+					md.TakenAt = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, fixed)
+					md.HasOffset = true
+				}
+			}
+			if !md.HasOffset {
+				md.TakenAt = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, loc)
+			}
+		}
+	}
+
+	return md, nil
+}
+
+func ReadPhotoMetadataFile(path string, loc *time.Location) (*PhotoMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	md, err := ReadPhotoMetadata(f, loc)
+	if err == ErrNoExif || err == ErrNotJpeg {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	md.Path = path
+
+	return md, nil
+}
@@ -0,0 +1,324 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+type testTiffEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	data  []byte
+	// ifd, when not zero, makes the entry a LONG pointing at ifds[ifd].
+	ifd int
+}
+
+// buildTiff lays out little endian IFDs one after the other, IFD zero
+// first, each followed by the values too large for their entries.
+func buildTiff(ifds ...[]testTiffEntry) []byte {
+	order := binary.LittleEndian
+
+	offsets := make([]uint32, len(ifds))
+	at := uint32(8)
+	for i, entries := range ifds {
+		offsets[i] = at
+		at += 2 + uint32(len(entries))*12 + 4
+		for _, e := range entries {
+			if len(e.data) > 4 {
+				at += uint32(len(e.data)+1) &^ 1
+			}
+		}
+	}
+
+	data := make([]byte, at)
+	copy(data, "II")
+	order.PutUint16(data[2:], 42)
+	order.PutUint32(data[4:], offsets[0])
+
+	for i, entries := range ifds {
+		base := offsets[i]
+		extra := base + 2 + uint32(len(entries))*12 + 4
+		order.PutUint16(data[base:], uint16(len(entries)))
+		for j, e := range entries {
+			p := data[base+2+uint32(j)*12:]
+			if e.ifd != 0 {
+				e.kind, e.count, e.data = tiffLong, 1, make([]byte, 4)
+				order.PutUint32(e.data, offsets[e.ifd])
+			}
+			order.PutUint16(p[0:], e.tag)
+			order.PutUint16(p[2:], e.kind)
+			order.PutUint32(p[4:], e.count)
+			if len(e.data) <= 4 {
+				copy(p[8:12], e.data)
+			} else {
+				order.PutUint32(p[8:], extra)
+				copy(data[extra:], e.data)
+				extra += uint32(len(e.data)+1) &^ 1
+			}
+		}
+	}
+
+	return data
+}
+
+func asciiEntry(tag uint16, s string) testTiffEntry {
+	return testTiffEntry{tag: tag, kind: tiffAscii, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func rationalEntry(tag uint16, values ...[2]uint32) testTiffEntry {
+	data := make([]byte, len(values)*8)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*8:], v[0])
+		binary.LittleEndian.PutUint32(data[i*8+4:], v[1])
+	}
+	return testTiffEntry{tag: tag, kind: tiffRational, count: uint32(len(values)), data: data}
+}
+
+func wrapJpeg(tiff []byte) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xff, 0xd8})
+	// An unrelated APP0 segment before the EXIF.
+	b.Write([]byte{0xff, 0xe0, 0x00, 0x04, 0x00, 0x00})
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	b.Write([]byte{0xff, 0xe1})
+	binary.Write(&b, binary.BigEndian, uint16(len(segment)+2))
+	b.Write(segment)
+	b.Write([]byte{0xff, 0xda})
+	return b.Bytes()
+}
+
+func testGpsIfd(date string, h, m, s uint32) []testTiffEntry {
+	return []testTiffEntry{
+		asciiEntry(tagGpsLatitudeRef, "S"),
+		rationalEntry(tagGpsLatitude, [2]uint32{33, 1}, [2]uint32{51, 1}, [2]uint32{3600, 100}),
+		asciiEntry(tagGpsLongitudeRef, "W"),
+		rationalEntry(tagGpsLongitude, [2]uint32{70, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+		rationalEntry(tagGpsHPositioningError, [2]uint32{125, 10}),
+		asciiEntry(tagGpsDateStamp, date),
+		rationalEntry(tagGpsTimeStamp, [2]uint32{h, 1}, [2]uint32{m, 1}, [2]uint32{s, 1}),
+	}
+}
+
+func TestReadPhotoMetadata(t *testing.T) {
+	loc := time.FixedZone("", 3*3600)
+
+	tests := []struct {
+		name      string
+		ifds      [][]testTiffEntry
+		takenAt   string
+		hasOffset bool
+		location  *Location
+		accuracy  float64
+	}{
+		{
+			name: "recorded offset",
+			ifds: [][]testTiffEntry{
+				{asciiEntry(tagMake, "Acme"), {tag: tagExifIfd, ifd: 1}, {tag: tagGpsIfd, ifd: 2}},
+				{asciiEntry(tagDateTimeOriginal, "2019:06:01 14:31:10"), asciiEntry(tagOffsetTimeOriginal, "+02:00")},
+				testGpsIfd("2019:06:01", 21, 30, 55),
+			},
+			takenAt:   "2019-06-01T14:31:10+02:00",
+			hasOffset: true,
+			location:  &Location{Latitude: -33.86, Longitude: -70.5},
+			accuracy:  12.5,
+		},
+		{
+			name: "offset derived from GPS",
+			ifds: [][]testTiffEntry{
+				{{tag: tagExifIfd, ifd: 1}, {tag: tagGpsIfd, ifd: 2}},
+				{asciiEntry(tagDateTimeOriginal, "2019:06:01 14:31:10")},
+				testGpsIfd("2019:06:01", 21, 30, 55),
+			},
+			takenAt:   "2019-06-01T14:31:10-07:00",
+			hasOffset: true,
+			location:  &Location{Latitude: -33.86, Longitude: -70.5},
+			accuracy:  12.5,
+		},
+		{
+			name: "offset derived across midnight",
+			ifds: [][]testTiffEntry{
+				{{tag: tagExifIfd, ifd: 1}, {tag: tagGpsIfd, ifd: 2}},
+				{asciiEntry(tagDateTimeOriginal, "2019:06:02 05:15:00")},
+				testGpsIfd("2019:06:01", 23, 30, 0),
+			},
+			takenAt:   "2019-06-02T05:15:00+05:45",
+			hasOffset: true,
+			location:  &Location{Latitude: -33.86, Longitude: -70.5},
+			accuracy:  12.5,
+		},
+		{
+			name: "GPS too far off to be an offset",
+			ifds: [][]testTiffEntry{
+				{{tag: tagExifIfd, ifd: 1}, {tag: tagGpsIfd, ifd: 2}},
+				{asciiEntry(tagDateTimeOriginal, "2019:06:01 14:31:10")},
+				testGpsIfd("2019:05:30", 0, 0, 0),
+			},
+			takenAt:  "2019-06-01T14:31:10+03:00",
+			location: &Location{Latitude: -33.86, Longitude: -70.5},
+			accuracy: 12.5,
+		},
+		{
+			name: "no offset or GPS",
+			ifds: [][]testTiffEntry{
+				{asciiEntry(tagDateTime, "2019:06:01 14:31:10")},
+			},
+			takenAt: "2019-06-01T14:31:10+03:00",
+		},
+		{
+			name: "zero denominator",
+			ifds: [][]testTiffEntry{
+				{{tag: tagGpsIfd, ifd: 1}},
+				{
+					asciiEntry(tagGpsLatitudeRef, "N"),
+					rationalEntry(tagGpsLatitude, [2]uint32{33, 0}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+					asciiEntry(tagGpsLongitudeRef, "E"),
+					rationalEntry(tagGpsLongitude, [2]uint32{70, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+				},
+			},
+		},
+		{
+			name: "value out of range",
+			ifds: [][]testTiffEntry{
+				{{tag: tagDateTime, kind: tiffAscii, count: 1000, data: []byte("2019:06:01 14:31:10\x00")}},
+			},
+		},
+		{
+			name: "unknown type",
+			ifds: [][]testTiffEntry{
+				{{tag: tagMake, kind: 99, count: 1, data: []byte{1}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data := buildTiff(test.ifds...)
+		if test.name == "value out of range" {
+			// Point the value well past the end of the data.
+			binary.LittleEndian.PutUint32(data[8+2+8:], uint32(len(data)+100))
+		}
+
+		md, err := ReadPhotoMetadata(bytes.NewReader(wrapJpeg(data)), loc)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		takenAt := ""
+		if !md.TakenAt.IsZero() {
+			takenAt = md.TakenAt.Format(time.RFC3339)
+		}
+		if takenAt != test.takenAt || md.HasOffset != test.hasOffset {
+			t.Errorf("%s: expected %s (offset %v), got %s (offset %v)", test.name, test.takenAt, test.hasOffset, takenAt, md.HasOffset)
+		}
+
+		if (md.Location == nil) != (test.location == nil) {
+			t.Errorf("%s: expected location %v, got %v", test.name, test.location, md.Location)
+		} else if md.Location != nil {
+			if math.Abs(md.Location.Latitude-test.location.Latitude) > 1e-9 || math.Abs(md.Location.Longitude-test.location.Longitude) > 1e-9 {
+				t.Errorf("%s: expected location %v, got %v", test.name, *test.location, *md.Location)
+			}
+		}
+		if md.Accuracy != test.accuracy {
+			t.Errorf("%s: expected accuracy %v, got %v", test.name, test.accuracy, md.Accuracy)
+		}
+	}
+}
+
+func TestReadPhotoMetadataMalformed(t *testing.T) {
+	valid := buildTiff([]testTiffEntry{asciiEntry(tagMake, "Acme")})
+
+	truncatedIfd := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint16(truncatedIfd[8:], 50)
+
+	ifdOutOfRange := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(ifdOutOfRange[4:], 0xfffffff0)
+
+	badOrder := append([]byte{}, valid...)
+	copy(badOrder, "XX")
+
+	badMagic := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint16(badMagic[2:], 43)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"PNG", []byte("\x89PNG\r\n\x1a\n"), ErrNotJpeg},
+		{"GIF", []byte("GIF89a"), ErrNotJpeg},
+		{"empty", []byte{}, ErrNotJpeg},
+		{"one byte", []byte{0xff}, ErrNotJpeg},
+		{"no EXIF", []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x04, 0x00, 0x00, 0xff, 0xda}, ErrNoExif},
+		{"truncated segment", []byte{0xff, 0xd8, 0xff, 0xe1, 0x01, 0x00, 'E', 'x'}, nil},
+		{"invalid segment length", []byte{0xff, 0xd8, 0xff, 0xe1, 0x00, 0x01}, nil},
+		{"invalid marker", []byte{0xff, 0xd8, 0x00, 0xe1}, nil},
+		{"truncated TIFF header", wrapJpeg(valid[:6]), nil},
+		{"bad byte order", wrapJpeg(badOrder), nil},
+		{"bad magic", wrapJpeg(badMagic), nil},
+		{"IFD out of range", wrapJpeg(ifdOutOfRange), nil},
+		{"IFD truncated", wrapJpeg(truncatedIfd), nil},
+	}
+
+	for _, test := range tests {
+		md, err := ReadPhotoMetadata(bytes.NewReader(test.data), time.UTC)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %+v", test.name, md)
+			continue
+		}
+		// Malformed JPEGs fail with their own errors.
+		if test.expected != nil && err != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+		if test.expected == nil && (err == ErrNoExif || err == ErrNotJpeg) {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+
+	// A pointer to a sub IFD that's out of range is ignored.
+	pointing := buildTiff([]testTiffEntry{asciiEntry(tagMake, "Acme"), {tag: tagExifIfd, kind: tiffLong, count: 1, data: []byte{0xf0, 0xff, 0xff, 0xff}}})
+	md, err := ReadPhotoMetadata(bytes.NewReader(wrapJpeg(pointing)), time.UTC)
+	if err != nil || md.Make != "Acme" {
+		t.Errorf("expected a bad Exif IFD pointer to be ignored, got %+v, %v", md, err)
+	}
+}
+
+func TestOffsetFromGps(t *testing.T) {
+	gps := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	local := func(d time.Duration) time.Time {
+		return time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC).Add(d)
+	}
+
+	tests := []struct {
+		local    time.Time
+		offset   int
+		expected bool
+	}{
+		{local(0), 0, true},
+		{local(2*time.Hour + 7*time.Second), 2 * 3600, true},
+		{local(-7*time.Hour + 20*time.Second), -7 * 3600, true},
+		{local(5*time.Hour + 44*time.Minute), 5*3600 + 45*60, true},
+		{local(9*time.Hour + 28*time.Minute), 9*3600 + 30*60, true},
+		{local(14 * time.Hour), 14 * 3600, true},
+		{local(-14 * time.Hour), -14 * 3600, true},
+		{local(15 * time.Hour), 0, false},
+		{local(-48 * time.Hour), 0, false},
+	}
+
+	for _, test := range tests {
+		zone, ok := offsetFromGps(test.local, gps)
+		if ok != test.expected {
+			t.Errorf("%v: expected ok %v", test.local.Sub(gps), test.expected)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if _, offset := time.Now().In(zone).Zone(); offset != test.offset {
+			t.Errorf("%v: expected offset %d, got %d", test.local.Sub(gps), test.offset, offset)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// GetObservationsContext is GetObservations with a context that cancels the
+// request, including any wait for the rate limiter.
+func (c *Client) GetObservationsContext(ctx context.Context, opt *GetObservationsOpt) (*ObservationsPage, error) {
+	queries, err := observationQueries(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ObservationsPage{
+		Observations: make([]*SimpleObservation, 0),
+	}
+	for _, query := range queries {
+		var result []*SimpleObservation
+
+		p, err := c.getWith(ctx, c.observationsUrl(query), func(r io.Reader) error {
+			return json.NewDecoder(r).Decode(&result)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error getting observations: %v", err)
+		}
+
+		page.Observations = append(page.Observations, result...)
+		page.Paging = mergePaging(page.Paging, p)
+	}
+
+	return page, nil
+}
+
+type pageResult struct {
+	number int
+	page   *ObservationsPage
+	err    error
+}
+
+// FetchObservationPages gets the page opt asks for and then every later
+// page using workers concurrent requests, calling fn with each page in
+// order. The client's rate limiter is shared by the workers. The first
+// error, from a request or from fn, cancels everything in flight and is
+// returned, as is ctx being cancelled. A rectangle crossing the
+// antimeridian is fetched one half after the other, each half's pages
+// being ordered and counted separately.
+func (c *Client) FetchObservationPages(ctx context.Context, opt *GetObservationsOpt, workers int, fn func(page *ObservationsPage) error) error {
+	if opt == nil {
+		opt = &GetObservationsOpt{}
+	}
+	if opt.Rectangle != nil && opt.Rectangle.CrossesAntimeridian() {
+		for _, r := range opt.Rectangle.Split() {
+			half := *opt
+			half.Rectangle = &r
+			if err := c.FetchObservationPages(ctx, &half, workers, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	first, err := c.GetObservationsContext(ctx, opt)
+	if err != nil {
+		return err
+	}
+	if err := fn(first); err != nil {
+		return err
+	}
+
+	paging := first.Paging
+	if paging == nil || paging.PerPage == 0 || len(first.Observations) == 0 {
+		return nil
+	}
+	last := (paging.TotalEntries + paging.PerPage - 1) / paging.PerPage
+	if paging.Page >= last {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// window bounds how far workers get ahead of the page fn is waiting
+	// for, so a slow early page doesn't leave every later one in memory.
+	window := make(chan struct{}, workers*2)
+	pages := make(chan int)
+	results := make(chan *pageResult)
+
+	go func() {
+		defer close(pages)
+		for number := paging.Page + 1; number <= last; number++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case pages <- number:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range pages {
+				pageOpt := *opt
+				n := number
+				pageOpt.Page = &n
+				perPage := paging.PerPage
+				pageOpt.PerPage = &perPage
+
+				page, err := c.GetObservationsContext(ctx, &pageOpt)
+				select {
+				case results <- &pageResult{number: number, page: page, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]*ObservationsPage)
+	next := paging.Page + 1
+	for r := range results {
+		if r.err != nil {
+			cancel()
+			err = r.err
+			break
+		}
+
+		pending[r.number] = r.page
+		for {
+			page, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			if err = fn(page); err != nil {
+				cancel()
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// Drain so workers blocked sending results can see the cancellation.
+	for range results {
+	}
+
+	if err == nil && next <= last {
+		err = ctx.Err()
+	}
+
+	return err
+}
+
+// GetAllObservations collects every page FetchObservationPages fetches.
+func (c *Client) GetAllObservations(ctx context.Context, opt *GetObservationsOpt, workers int) ([]*SimpleObservation, error) {
+	all := make([]*SimpleObservation, 0)
+	err := c.FetchObservationPages(ctx, opt, workers, func(page *ObservationsPage) error {
+		all = append(all, page.Observations...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
@@ -0,0 +1,153 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// pagedObservations serves total observations perPage at a time, each
+// page's ids starting after the last page's.
+func pagedObservations(total, perPage int, delay func(page int) time.Duration, fail func(page int) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if fail != nil && fail(page) {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		if delay != nil {
+			select {
+			case <-time.After(delay(page)):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("X-Total-Entries", strconv.Itoa(total))
+		w.Header().Set("X-Page", strconv.Itoa(page))
+		w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+		fmt.Fprint(w, "[")
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			if i > (page-1)*perPage {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":%d}`, i+1)
+		}
+		fmt.Fprint(w, "]")
+	})
+}
+
+func TestFetchObservationPagesInOrder(t *testing.T) {
+	tests := []struct {
+		total   int
+		perPage int
+		workers int
+	}{
+		{0, 5, 4},
+		{3, 5, 4},
+		{23, 5, 1},
+		{23, 5, 4},
+		{40, 2, 8},
+	}
+
+	for _, test := range tests {
+		// Later pages answer sooner so they arrive out of order.
+		delay := func(page int) time.Duration {
+			return time.Duration(20-page%20) * time.Millisecond
+		}
+		c, server := newTestClient(t, pagedObservations(test.total, test.perPage, delay, nil))
+
+		all, err := c.GetAllObservations(context.Background(), &GetObservationsOpt{}, test.workers)
+		server.Close()
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+
+		if len(all) != test.total {
+			t.Errorf("%+v: expected %d observations, got %d", test, test.total, len(all))
+			continue
+		}
+		for i, o := range all {
+			if o.Id != int64(i+1) {
+				t.Errorf("%+v: expected id %d at %d, got %d", test, i+1, i, o.Id)
+				break
+			}
+		}
+	}
+}
+
+func TestFetchObservationPagesCancelsOnFirstError(t *testing.T) {
+	var finished int32
+	handler := pagedObservations(100, 5, nil, func(page int) bool {
+		return page == 3
+	})
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page > 3 {
+			// Hold later pages until the request is cancelled.
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+				atomic.AddInt32(&finished, 1)
+			}
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- c.FetchObservationPages(context.Background(), &GetObservationsOpt{}, 4, func(page *ObservationsPage) error {
+			calls++
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the failing page's error")
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatalf("requests in flight weren't cancelled")
+	}
+
+	// Page 2 may or may not arrive before page 3 fails.
+	if calls < 1 || calls > 2 {
+		t.Errorf("expected only pages before the failure, got %d", calls)
+	}
+	if atomic.LoadInt32(&finished) != 0 {
+		t.Errorf("expected every request in flight to be cancelled")
+	}
+}
+
+func TestFetchObservationPagesStopsOnCallbackError(t *testing.T) {
+	c, server := newTestClient(t, pagedObservations(50, 5, nil, nil))
+	defer server.Close()
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err := c.FetchObservationPages(context.Background(), &GetObservationsOpt{}, 4, func(page *ObservationsPage) error {
+		calls++
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("expected the callback's error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,271 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TimePrecision int
+
+const (
+	UnknownPrecision TimePrecision = iota
+	YearPrecision
+	MonthPrecision
+	DayPrecision
+	MinutePrecision
+	SecondPrecision
+)
+
+func (p TimePrecision) String() string {
+	switch p {
+	case YearPrecision:
+		return "year"
+	case MonthPrecision:
+		return "month"
+	case DayPrecision:
+		return "day"
+	case MinutePrecision:
+		return "minute"
+	case SecondPrecision:
+		return "second"
+	}
+	return "unknown"
+}
+
+// FuzzyDate is a point in time along with how much of it is actually
+// known, observations may only have a year or month. Fields beyond the
+// precision are zero.
+type FuzzyDate struct {
+	Time      time.Time
+	Precision TimePrecision
+}
+
+func NewFuzzyDate(t time.Time, precision TimePrecision) FuzzyDate {
+	return FuzzyDate{
+		Time:      truncateToPrecision(t, precision),
+		Precision: precision,
+	}
+}
+
+func truncateToPrecision(t time.Time, precision TimePrecision) time.Time {
+	switch precision {
+	case YearPrecision:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	case MonthPrecision:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case DayPrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case MinutePrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	case SecondPrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	}
+	return t
+}
+
+func (t FuzzyDate) IsSet() bool {
+	return t.Precision != UnknownPrecision
+}
+
+func (t FuzzyDate) HasTimeOfDay() bool {
+	return t.Precision >= MinutePrecision
+}
+
+// Date is the calendar date in the time's location.
+func (t FuzzyDate) Date() string {
+	return t.Time.Format("2006-01-02")
+}
+
+// String formats only the known part, e.g. "2019-05".
+func (t FuzzyDate) String() string {
+	switch t.Precision {
+	case YearPrecision:
+		return t.Time.Format("2006")
+	case MonthPrecision:
+		return t.Time.Format("2006-01")
+	case DayPrecision:
+		return t.Time.Format("2006-01-02")
+	case MinutePrecision:
+		return t.Time.Format("2006-01-02T15:04Z07:00")
+	case SecondPrecision:
+		return t.Time.Format(time.RFC3339)
+	}
+	return ""
+}
+
+func (t FuzzyDate) MarshalJSON() ([]byte, error) {
+	if !t.IsSet() {
+		return []byte("null"), nil
+	}
+	return []byte(fmt.Sprintf("\"%s\"", t.String())), nil
+}
+
+func (t *FuzzyDate) UnmarshalJSON(b []byte) error {
+	parsed, err := parseFuzzyDate(string(b), time.UTC, false)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Covers returns the range of times the date could refer to, end exclusive.
+func (t FuzzyDate) Covers() (start time.Time, end time.Time) {
+	start = t.Time
+	switch t.Precision {
+	case YearPrecision:
+		end = start.AddDate(1, 0, 0)
+	case MonthPrecision:
+		end = start.AddDate(0, 1, 0)
+	case DayPrecision:
+		end = start.AddDate(0, 0, 1)
+	case MinutePrecision:
+		end = start.Add(time.Minute)
+	default:
+		end = start.Add(time.Second)
+	}
+	return
+}
+
+type observedOnLayout struct {
+	layout    string
+	precision TimePrecision
+}
+
+var observedOnLayouts = []observedOnLayout{
+	{time.RFC3339, SecondPrecision},
+	{"2006-01-02 15:04:05 -0700", SecondPrecision},
+	{"2006-01-02 15:04:05 -07:00", SecondPrecision},
+	{"2006-01-02 15:04:05 MST", SecondPrecision},
+	{"2006-01-02T15:04Z07:00", MinutePrecision},
+	{"2006-01-02 15:04 -07:00", MinutePrecision},
+	{"Mon Jan 02 2006 15:04:05 GMT-0700", SecondPrecision},
+	{"2006/01/02 3:04 PM MST", MinutePrecision},
+	{"2006/01/02 3:04 PM -07:00", MinutePrecision},
+	{"2006-01-02T15:04:05", SecondPrecision},
+	{"2006-01-02 15:04:05", SecondPrecision},
+	{"2006-01-02T15:04", MinutePrecision},
+	{"2006-01-02 15:04", MinutePrecision},
+	{"2006/01/02 3:04 PM", MinutePrecision},
+	{"2006/01/02 15:04", MinutePrecision},
+	{"2006-01-02", DayPrecision},
+	{"2006/01/02", DayPrecision},
+	{"January 2, 2006", DayPrecision},
+	{"2006-01", MonthPrecision},
+	{"2006/01", MonthPrecision},
+	{"January 2006", MonthPrecision},
+	{"Jan 2006", MonthPrecision},
+	{"2006", YearPrecision},
+}
+
+// ParseFuzzyDateIn parses the forms the server and its clients use for
+// observed_on_string, from a year to a full timestamp. Strings without an
+// offset are local times in loc and the result is always expressed in loc,
+// so its date is the local date.
+func ParseFuzzyDateIn(s string, loc *time.Location) (FuzzyDate, error) {
+	return parseFuzzyDate(s, loc, true)
+}
+
+func parseFuzzyDate(s string, loc *time.Location, convert bool) (FuzzyDate, error) {
+	str := strings.TrimSpace(strings.Trim(s, "\""))
+	if str == "null" || str == "" {
+		return FuzzyDate{}, nil
+	}
+	// Browsers append the zone's name, e.g. "GMT-0700 (PDT)".
+	if i := strings.Index(str, " ("); i > 0 && strings.HasSuffix(str, ")") {
+		str = str[:i]
+	}
+	for _, l := range observedOnLayouts {
+		t, err := time.ParseInLocation(l.layout, str, loc)
+		if err == nil {
+			if convert {
+				t = t.In(loc)
+			}
+			return FuzzyDate{
+				Time:      t,
+				Precision: l.precision,
+			}, nil
+		}
+	}
+	return FuzzyDate{}, fmt.Errorf("Unable to parse time: '%s'", s)
+}
+
+// ParseFuzzyDate parses in a Rails or IANA named time zone.
+func ParseFuzzyDate(s string, zone string) (FuzzyDate, error) {
+	loc, err := LoadTimeZone(zone)
+	if err != nil {
+		return FuzzyDate{}, err
+	}
+	return ParseFuzzyDateIn(s, loc)
+}
+
+// FormatObservedOnString renders t as an observed_on_string along with the
+// time_zone the server should interpret it in. Times in zones the server
+// doesn't know are sent with an explicit offset instead.
+func FormatObservedOnString(t time.Time, precision TimePrecision) (value string, timeZone string) {
+	name, named := RailsTimeZoneName(t.Location())
+
+	switch precision {
+	case YearPrecision:
+		return t.Format("2006"), name
+	case MonthPrecision:
+		return t.Format("2006-01"), name
+	case DayPrecision:
+		return t.Format("2006-01-02"), name
+	case MinutePrecision:
+		if named {
+			return t.Format("2006-01-02 15:04"), name
+		}
+		return t.Format("2006-01-02 15:04 -07:00"), ""
+	}
+
+	if named {
+		return t.Format("2006-01-02 15:04:05"), name
+	}
+	return t.Format(time.RFC3339), ""
+}
+
+func (o *SimpleObservation) TimeZoneLocation() (*time.Location, error) {
+	if o.ZicTimeZone != "" {
+		return LoadTimeZone(o.ZicTimeZone)
+	}
+	return LoadTimeZone(o.TimeZone)
+}
+
+// ObservedTime interprets the observation's time in its own time zone. The
+// server's UTC time is preferred when known, observed_on_string is used for
+// the precision and as a fallback.
+func (o *SimpleObservation) ObservedTime() (FuzzyDate, error) {
+	loc, err := o.TimeZoneLocation()
+	if err != nil {
+		return FuzzyDate{}, err
+	}
+
+	parsed, err := ParseFuzzyDateIn(o.ObservedOnString, loc)
+	if err == nil && parsed.IsSet() {
+		if parsed.HasTimeOfDay() && !o.TimeObservedAtUtc.IsZero() {
+			parsed.Time = o.TimeObservedAtUtc.In(loc)
+		}
+		return parsed, nil
+	}
+
+	if !o.TimeObservedAtUtc.IsZero() {
+		return FuzzyDate{
+			Time:      o.TimeObservedAtUtc.In(loc),
+			Precision: SecondPrecision,
+		}, nil
+	}
+
+	if o.ObservedOn != "" {
+		day, dayErr := time.ParseInLocation("2006-01-02", o.ObservedOn, loc)
+		if dayErr == nil {
+			return FuzzyDate{
+				Time:      day,
+				Precision: DayPrecision,
+			}, nil
+		}
+	}
+
+	return parsed, err
+}
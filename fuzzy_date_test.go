@@ -0,0 +1,139 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFuzzyDateRoundTrips(t *testing.T) {
+	pacific, err := LoadTimeZone("Pacific Time (US & Canada)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	odd := time.FixedZone("", 5*3600+45*60)
+
+	tests := []struct {
+		t         time.Time
+		precision TimePrecision
+		value     string
+		zone      string
+	}{
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, pacific), YearPrecision, "2017", "Pacific Time (US & Canada)"},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, pacific), MonthPrecision, "2017-03", "Pacific Time (US & Canada)"},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, pacific), DayPrecision, "2017-03-04", "Pacific Time (US & Canada)"},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, pacific), MinutePrecision, "2017-03-04 10:30", "Pacific Time (US & Canada)"},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, pacific), SecondPrecision, "2017-03-04 10:30:15", "Pacific Time (US & Canada)"},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, odd), MinutePrecision, "2017-03-04 10:30 +05:45", ""},
+		{time.Date(2017, 3, 4, 10, 30, 15, 0, odd), SecondPrecision, "2017-03-04T10:30:15+05:45", ""},
+	}
+
+	for _, test := range tests {
+		date := NewFuzzyDate(test.t, test.precision)
+
+		value, zone := FormatObservedOnString(date.Time, date.Precision)
+		if value != test.value || zone != test.zone {
+			t.Errorf("%v: expected %q in %q, got %q in %q", test.precision, test.value, test.zone, value, zone)
+			continue
+		}
+
+		parsed, err := ParseFuzzyDate(value, zone)
+		if err != nil {
+			t.Errorf("%v: %v", test.precision, err)
+			continue
+		}
+		if parsed.Precision != date.Precision || !parsed.Time.Equal(date.Time) {
+			t.Errorf("%v: expected %v, got %v", test.precision, date, parsed)
+		}
+		if parsed.Date() != date.Date() {
+			t.Errorf("%v: expected local date %s, got %s", test.precision, date.Date(), parsed.Date())
+		}
+
+		b, err := json.Marshal(date)
+		if err != nil {
+			t.Errorf("%v: %v", test.precision, err)
+			continue
+		}
+		var decoded FuzzyDate
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Errorf("%v: %v", test.precision, err)
+			continue
+		}
+		if decoded.Precision != date.Precision || decoded.String() != date.String() {
+			t.Errorf("%v: expected %s to decode to %v, got %v", test.precision, b, date, decoded)
+		}
+	}
+}
+
+func TestParseFuzzyDatePrecision(t *testing.T) {
+	tests := []struct {
+		value     string
+		precision TimePrecision
+		expected  string
+	}{
+		{"2017", YearPrecision, "2017"},
+		{"March 2017", MonthPrecision, "2017-03"},
+		{"2017/03", MonthPrecision, "2017-03"},
+		{"March 4, 2017", DayPrecision, "2017-03-04"},
+		{"2017/03/04", DayPrecision, "2017-03-04"},
+		{"2017/03/04 10:30 AM", MinutePrecision, "2017-03-04T10:30Z"},
+		{"2017-03-04T10:30:15Z", SecondPrecision, "2017-03-04T10:30:15Z"},
+		{"\"2017-03-04\"", DayPrecision, "2017-03-04"},
+		{"", UnknownPrecision, ""},
+		{"null", UnknownPrecision, ""},
+	}
+
+	for _, test := range tests {
+		parsed, err := ParseFuzzyDateIn(test.value, time.UTC)
+		if err != nil {
+			t.Errorf("%q: %v", test.value, err)
+			continue
+		}
+		if parsed.Precision != test.precision || parsed.String() != test.expected {
+			t.Errorf("%q: expected %s (%v), got %s (%v)", test.value, test.expected, test.precision, parsed, parsed.Precision)
+		}
+	}
+
+	if _, err := ParseFuzzyDateIn("yesterday", time.UTC); err == nil {
+		t.Errorf("expected an error for an unparseable date")
+	}
+}
+
+func TestParseFuzzyDateInConvertsToZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20:00 in California is the next day in Tokyo.
+	parsed, err := ParseFuzzyDateIn("2017-03-04T20:00:00-08:00", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Date() != "2017-03-05" || parsed.Time.Location() != tokyo {
+		t.Errorf("expected the local date in Tokyo, got %v", parsed.Time)
+	}
+}
+
+func TestFuzzyDateCovers(t *testing.T) {
+	at := time.Date(2016, 2, 29, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		precision TimePrecision
+		start     time.Time
+		end       time.Time
+	}{
+		{YearPrecision, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{MonthPrecision, time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{DayPrecision, time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{MinutePrecision, time.Date(2016, 2, 29, 10, 30, 0, 0, time.UTC), time.Date(2016, 2, 29, 10, 31, 0, 0, time.UTC)},
+		{SecondPrecision, time.Date(2016, 2, 29, 10, 30, 15, 0, time.UTC), time.Date(2016, 2, 29, 10, 30, 16, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		start, end := NewFuzzyDate(at, test.precision).Covers()
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("%v: expected %v to %v, got %v to %v", test.precision, test.start, test.end, start, end)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	EarthRadiusKm = 6371.0088
+
+	kmPerDegreeLatitude = 111.32
+)
+
+type Location struct {
+	Longitude float64
+	Latitude  float64
+}
+
+type Rectangle struct {
+	Southwest Location
+	Northeast Location
+}
+
+func toRadians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func toDegrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
+
+// normalizeLongitude wraps a longitude into [-180, 180].
+func normalizeLongitude(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// longitudeSpan is the eastward distance in degrees from west to east.
+func longitudeSpan(west, east float64) float64 {
+	span := east - west
+	if span < 0 {
+		span += 360
+	}
+	return span
+}
+
+func (l Location) Validate() error {
+	if math.IsNaN(l.Latitude) || l.Latitude < -90 || l.Latitude > 90 {
+		return fmt.Errorf("Invalid latitude: %v", l.Latitude)
+	}
+	if math.IsNaN(l.Longitude) || l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("Invalid longitude: %v", l.Longitude)
+	}
+	return nil
+}
+
+// DistanceKm is the great circle distance between the locations.
+func (l Location) DistanceKm(o Location) float64 {
+	lat1, lat2 := toRadians(l.Latitude), toRadians(o.Latitude)
+	dLat := lat2 - lat1
+	dLon := toRadians(o.Longitude - l.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Bearing is the initial bearing from l to o in degrees clockwise from north.
+func (l Location) Bearing(o Location) float64 {
+	lat1, lat2 := toRadians(l.Latitude), toRadians(o.Latitude)
+	dLon := toRadians(o.Longitude - l.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// Validate checks the corners are valid locations with the southwest corner
+// below the northeast one. A southwest longitude east of the northeast
+// longitude is allowed and means the rectangle crosses the antimeridian.
+func (r Rectangle) Validate() error {
+	if err := r.Southwest.Validate(); err != nil {
+		return fmt.Errorf("Invalid southwest corner: %v", err)
+	}
+	if err := r.Northeast.Validate(); err != nil {
+		return fmt.Errorf("Invalid northeast corner: %v", err)
+	}
+	if r.Southwest.Latitude > r.Northeast.Latitude {
+		return fmt.Errorf("Southwest corner is north of northeast corner")
+	}
+	return nil
+}
+
+func (r Rectangle) CrossesAntimeridian() bool {
+	return r.Southwest.Longitude > r.Northeast.Longitude
+}
+
+// Split returns the rectangle as one or two rectangles that don't cross the
+// antimeridian.
+func (r Rectangle) Split() []Rectangle {
+	if !r.CrossesAntimeridian() {
+		return []Rectangle{r}
+	}
+	return []Rectangle{
+		{
+			Southwest: r.Southwest,
+			Northeast: Location{Longitude: 180, Latitude: r.Northeast.Latitude},
+		},
+		{
+			Southwest: Location{Longitude: -180, Latitude: r.Southwest.Latitude},
+			Northeast: r.Northeast,
+		},
+	}
+}
+
+func (r Rectangle) widthDegrees() float64 {
+	return longitudeSpan(r.Southwest.Longitude, r.Northeast.Longitude)
+}
+
+func (r Rectangle) Contains(l Location) bool {
+	if l.Latitude < r.Southwest.Latitude || l.Latitude > r.Northeast.Latitude {
+		return false
+	}
+	return longitudeSpan(r.Southwest.Longitude, l.Longitude) <= r.widthDegrees()
+}
+
+func (r Rectangle) containsLongitudes(o Rectangle) bool {
+	start := longitudeSpan(r.Southwest.Longitude, o.Southwest.Longitude)
+	return start+o.widthDegrees() <= r.widthDegrees()
+}
+
+func (r Rectangle) Intersects(o Rectangle) bool {
+	if r.Northeast.Latitude < o.Southwest.Latitude || o.Northeast.Latitude < r.Southwest.Latitude {
+		return false
+	}
+	for _, a := range r.Split() {
+		for _, b := range o.Split() {
+			if a.Southwest.Longitude <= b.Northeast.Longitude && b.Southwest.Longitude <= a.Northeast.Longitude {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Union is the smallest rectangle covering both, which may cross the
+// antimeridian if that's narrower.
+func (r Rectangle) Union(o Rectangle) Rectangle {
+	union := Rectangle{
+		Southwest: Location{Latitude: math.Min(r.Southwest.Latitude, o.Southwest.Latitude)},
+		Northeast: Location{Latitude: math.Max(r.Northeast.Latitude, o.Northeast.Latitude)},
+	}
+
+	candidates := [][2]float64{
+		{r.Southwest.Longitude, r.Northeast.Longitude},
+		{o.Southwest.Longitude, o.Northeast.Longitude},
+		{r.Southwest.Longitude, o.Northeast.Longitude},
+		{o.Southwest.Longitude, r.Northeast.Longitude},
+	}
+
+	best := [2]float64{-180, 180}
+	for _, c := range candidates {
+		covering := Rectangle{
+			Southwest: Location{Longitude: c[0]},
+			Northeast: Location{Longitude: c[1]},
+		}
+		if !covering.containsLongitudes(r) || !covering.containsLongitudes(o) {
+			continue
+		}
+		if covering.widthDegrees() < longitudeSpan(best[0], best[1]) {
+			best = c
+		}
+	}
+
+	union.Southwest.Longitude = best[0]
+	union.Northeast.Longitude = best[1]
+
+	return union
+}
+
+// Expand grows the rectangle by km on every side. Latitudes are clamped at
+// the poles and longitudes wrap, covering the whole globe if necessary.
+func (r Rectangle) Expand(km float64) Rectangle {
+	dLat := km / kmPerDegreeLatitude
+
+	south := math.Max(-90, r.Southwest.Latitude-dLat)
+	north := math.Min(90, r.Northeast.Latitude+dLat)
+
+	widest := math.Max(math.Abs(south), math.Abs(north))
+	if widest >= 90 {
+		return Rectangle{
+			Southwest: Location{Longitude: -180, Latitude: south},
+			Northeast: Location{Longitude: 180, Latitude: north},
+		}
+	}
+
+	dLon := km / (kmPerDegreeLatitude * math.Cos(toRadians(widest)))
+	if r.widthDegrees()+2*dLon >= 360 {
+		return Rectangle{
+			Southwest: Location{Longitude: -180, Latitude: south},
+			Northeast: Location{Longitude: 180, Latitude: north},
+		}
+	}
+
+	return Rectangle{
+		Southwest: Location{Longitude: normalizeLongitude(r.Southwest.Longitude - dLon), Latitude: south},
+		Northeast: Location{Longitude: normalizeLongitude(r.Northeast.Longitude + dLon), Latitude: north},
+	}
+}
+
+func (r Rectangle) Center() Location {
+	return Location{
+		Longitude: normalizeLongitude(r.Southwest.Longitude + r.widthDegrees()/2),
+		Latitude:  (r.Southwest.Latitude + r.Northeast.Latitude) / 2,
+	}
+}
+
+// AreaKm2 is the area of the rectangle on a spherical earth.
+func (r Rectangle) AreaKm2() float64 {
+	dSin := math.Sin(toRadians(r.Northeast.Latitude)) - math.Sin(toRadians(r.Southwest.Latitude))
+	return EarthRadiusKm * EarthRadiusKm * dSin * toRadians(r.widthDegrees())
+}
+
+// Polygon returns the rectangle as a counter-clockwise GeoJSON polygon.
+func (r Rectangle) Polygon() Polygon {
+	sw, ne := r.Southwest, r.Northeast
+	return Polygon{
+		Ring{
+			{sw.Longitude, sw.Latitude},
+			{ne.Longitude, sw.Latitude},
+			{ne.Longitude, ne.Latitude},
+			{sw.Longitude, ne.Latitude},
+			{sw.Longitude, sw.Latitude},
+		},
+	}
+}
@@ -0,0 +1,183 @@
+package gonaturalist
+
+import (
+	"math"
+	"testing"
+)
+
+func closeTo(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestDistanceKm(t *testing.T) {
+	london := Location{Latitude: 51.5074, Longitude: -0.1278}
+	paris := Location{Latitude: 48.8566, Longitude: 2.3522}
+
+	tests := []struct {
+		a, b     Location
+		expected float64
+	}{
+		{london, paris, 343.6},
+		{paris, london, 343.6},
+		{london, london, 0},
+		{Location{Latitude: 0, Longitude: 179.5}, Location{Latitude: 0, Longitude: -179.5}, 111.2},
+		{Location{Latitude: 90, Longitude: 0}, Location{Latitude: -90, Longitude: 0}, math.Pi * EarthRadiusKm},
+	}
+
+	for _, test := range tests {
+		actual := test.a.DistanceKm(test.b)
+		if !closeTo(actual, test.expected, 0.5) {
+			t.Errorf("%+v to %+v: expected %v, got %v", test.a, test.b, test.expected, actual)
+		}
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		a, b     Location
+		expected float64
+	}{
+		{Location{Latitude: 51.5074, Longitude: -0.1278}, Location{Latitude: 48.8566, Longitude: 2.3522}, 148.1},
+		{Location{Latitude: 0, Longitude: 0}, Location{Latitude: 1, Longitude: 0}, 0},
+		{Location{Latitude: 0, Longitude: 0}, Location{Latitude: 0, Longitude: 1}, 90},
+		{Location{Latitude: 0, Longitude: 179.5}, Location{Latitude: 0, Longitude: -179.5}, 90},
+		{Location{Latitude: 0, Longitude: 0}, Location{Latitude: 0, Longitude: -1}, 270},
+	}
+
+	for _, test := range tests {
+		actual := test.a.Bearing(test.b)
+		if !closeTo(actual, test.expected, 0.1) {
+			t.Errorf("%+v to %+v: expected %v, got %v", test.a, test.b, test.expected, actual)
+		}
+	}
+}
+
+func TestRectangleValidate(t *testing.T) {
+	tests := []struct {
+		r     Rectangle
+		valid bool
+	}{
+		{Rectangle{Location{Longitude: -10, Latitude: -10}, Location{Longitude: 10, Latitude: 10}}, true},
+		{Rectangle{Location{Longitude: 170, Latitude: -10}, Location{Longitude: -170, Latitude: 10}}, true},
+		{Rectangle{Location{Longitude: -10, Latitude: 10}, Location{Longitude: 10, Latitude: -10}}, false},
+		{Rectangle{Location{Longitude: -190, Latitude: -10}, Location{Longitude: 10, Latitude: 10}}, false},
+		{Rectangle{Location{Longitude: -10, Latitude: -91}, Location{Longitude: 10, Latitude: 10}}, false},
+	}
+
+	for _, test := range tests {
+		err := test.r.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("%+v: expected valid %v, got %v", test.r, test.valid, err)
+		}
+	}
+}
+
+func TestRectangleSplit(t *testing.T) {
+	crossing := Rectangle{
+		Southwest: Location{Longitude: 170, Latitude: -20},
+		Northeast: Location{Longitude: -170, Latitude: -10},
+	}
+
+	halves := crossing.Split()
+	if len(halves) != 2 {
+		t.Fatalf("expected two halves, got %v", halves)
+	}
+	expected := []Rectangle{
+		{Location{Longitude: 170, Latitude: -20}, Location{Longitude: 180, Latitude: -10}},
+		{Location{Longitude: -180, Latitude: -20}, Location{Longitude: -170, Latitude: -10}},
+	}
+	for i := range expected {
+		if halves[i] != expected[i] {
+			t.Errorf("half %d: expected %+v, got %+v", i, expected[i], halves[i])
+		}
+		if halves[i].CrossesAntimeridian() {
+			t.Errorf("half %d crosses the antimeridian", i)
+		}
+	}
+
+	plain := Rectangle{
+		Southwest: Location{Longitude: -10, Latitude: -10},
+		Northeast: Location{Longitude: 10, Latitude: 10},
+	}
+	if split := plain.Split(); len(split) != 1 || split[0] != plain {
+		t.Errorf("expected %+v unchanged, got %+v", plain, split)
+	}
+}
+
+func TestRectangleContains(t *testing.T) {
+	crossing := Rectangle{
+		Southwest: Location{Longitude: 170, Latitude: -20},
+		Northeast: Location{Longitude: -170, Latitude: -10},
+	}
+	plain := Rectangle{
+		Southwest: Location{Longitude: -10, Latitude: -10},
+		Northeast: Location{Longitude: 10, Latitude: 10},
+	}
+
+	tests := []struct {
+		r        Rectangle
+		l        Location
+		expected bool
+	}{
+		{crossing, Location{Longitude: 175, Latitude: -15}, true},
+		{crossing, Location{Longitude: -175, Latitude: -15}, true},
+		{crossing, Location{Longitude: 180, Latitude: -15}, true},
+		{crossing, Location{Longitude: -180, Latitude: -15}, true},
+		{crossing, Location{Longitude: 0, Latitude: -15}, false},
+		{crossing, Location{Longitude: 169, Latitude: -15}, false},
+		{crossing, Location{Longitude: 175, Latitude: -5}, false},
+		{plain, Location{Longitude: 0, Latitude: 0}, true},
+		{plain, Location{Longitude: 10, Latitude: 10}, true},
+		{plain, Location{Longitude: 175, Latitude: 0}, false},
+	}
+
+	for _, test := range tests {
+		if actual := test.r.Contains(test.l); actual != test.expected {
+			t.Errorf("%+v contains %+v: expected %v", test.r, test.l, test.expected)
+		}
+	}
+}
+
+func TestRectangleIntersectsAndUnion(t *testing.T) {
+	west := Rectangle{Location{Longitude: 170, Latitude: 0}, Location{Longitude: 175, Latitude: 5}}
+	east := Rectangle{Location{Longitude: -175, Latitude: 0}, Location{Longitude: -170, Latitude: 5}}
+	crossing := Rectangle{Location{Longitude: 172, Latitude: 1}, Location{Longitude: -172, Latitude: 4}}
+
+	if west.Intersects(east) {
+		t.Errorf("expected %+v and %+v not to intersect", west, east)
+	}
+	if !crossing.Intersects(west) || !crossing.Intersects(east) {
+		t.Errorf("expected %+v to intersect both sides", crossing)
+	}
+
+	union := west.Union(east)
+	expected := Rectangle{Location{Longitude: 170, Latitude: 0}, Location{Longitude: -170, Latitude: 5}}
+	if union != expected {
+		t.Errorf("expected union %+v, got %+v", expected, union)
+	}
+	if !closeTo(union.widthDegrees(), 20, 1e-9) {
+		t.Errorf("expected the union to be 20 degrees wide, got %v", union.widthDegrees())
+	}
+}
+
+func TestRectangleCenterAndExpand(t *testing.T) {
+	crossing := Rectangle{Location{Longitude: 170, Latitude: -20}, Location{Longitude: -170, Latitude: -10}}
+
+	center := crossing.Center()
+	if !closeTo(math.Abs(center.Longitude), 180, 1e-9) || center.Latitude != -15 {
+		t.Errorf("expected center on the antimeridian, got %+v", center)
+	}
+
+	expanded := crossing.Expand(111.32)
+	if !closeTo(expanded.Southwest.Latitude, -21, 1e-9) || !closeTo(expanded.Northeast.Latitude, -9, 1e-9) {
+		t.Errorf("expected a degree of latitude either side, got %+v", expanded)
+	}
+	if !expanded.CrossesAntimeridian() || !expanded.Contains(Location{Longitude: 169.5, Latitude: -15}) {
+		t.Errorf("expected expanded rectangle to still cross, got %+v", expanded)
+	}
+
+	whole := crossing.Expand(20000)
+	if whole.Southwest.Longitude != -180 || whole.Northeast.Longitude != 180 {
+		t.Errorf("expected the whole globe, got %+v", whole)
+	}
+}
@@ -0,0 +1,54 @@
+package gonaturalist
+
+import (
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		location  Location
+		precision int
+		expected  string
+	}{
+		{Location{Latitude: 57.64911, Longitude: 10.40744}, 11, "u4pruydqqvj"},
+		{Location{Latitude: 42.6, Longitude: -5.6}, 5, "ezs42"},
+		{Location{Latitude: -33.8688, Longitude: 151.2093}, 6, "r3gx2f"},
+		{Location{Latitude: 0, Longitude: 0}, 1, "s"},
+		{Location{Latitude: -90, Longitude: -180}, 3, "000"},
+	}
+
+	for _, test := range tests {
+		actual := test.location.Geohash(test.precision)
+		if actual != test.expected {
+			t.Errorf("%+v: expected %s, got %s", test.location, test.expected, actual)
+		}
+	}
+}
+
+func TestGeohashBounds(t *testing.T) {
+	tests := []Location{
+		{Latitude: 57.64911, Longitude: 10.40744},
+		{Latitude: -33.8688, Longitude: 151.2093},
+		{Latitude: 64.1, Longitude: -179.99},
+	}
+
+	for _, l := range tests {
+		for precision := 1; precision <= 9; precision++ {
+			r, err := GeohashBounds(l.Geohash(precision))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Contains(l) {
+				t.Errorf("%+v: cell %v of precision %d doesn't contain it", l, r, precision)
+			}
+		}
+	}
+}
+
+func TestGeohashBoundsInvalid(t *testing.T) {
+	for _, hash := range []string{"a", "u4pi", "u4p!"} {
+		if _, err := GeohashBounds(hash); err == nil {
+			t.Errorf("%s: expected an error", hash)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	PolygonGeometry      = "Polygon"
+	MultiPolygonGeometry = "MultiPolygon"
+	PointGeometry        = "Point"
+)
+
+// GeoJsonGeometry is a GeoJSON geometry object. Coordinates are kept raw
+// and decoded on demand since their shape depends on Type.
+type GeoJsonGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Ring is a closed sequence of [longitude, latitude] positions.
+type Ring [][2]float64
+
+// Polygon is an outer ring followed by any holes.
+type Polygon []Ring
+
+func (g *GeoJsonGeometry) Polygons() ([]Polygon, error) {
+	switch g.Type {
+	case PolygonGeometry:
+		var p Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("Decoding polygon: %v", err)
+		}
+		return []Polygon{p}, nil
+	case MultiPolygonGeometry:
+		var mp []Polygon
+		if err := json.Unmarshal(g.Coordinates, &mp); err != nil {
+			return nil, fmt.Errorf("Decoding multipolygon: %v", err)
+		}
+		return mp, nil
+	}
+	return nil, fmt.Errorf("Unsupported geometry type: '%s'", g.Type)
+}
+
+func (g *GeoJsonGeometry) Contains(l Location) (bool, error) {
+	polygons, err := g.Polygons()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range polygons {
+		if p.Contains(l) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (g *GeoJsonGeometry) Bounds() (r Rectangle, err error) {
+	polygons, err := g.Polygons()
+	if err != nil {
+		return
+	}
+	first := true
+	for _, p := range polygons {
+		for _, ring := range p {
+			for _, c := range ring {
+				if first {
+					r.Southwest = Location{Longitude: c[0], Latitude: c[1]}
+					r.Northeast = r.Southwest
+					first = false
+					continue
+				}
+				if c[0] < r.Southwest.Longitude {
+					r.Southwest.Longitude = c[0]
+				}
+				if c[1] < r.Southwest.Latitude {
+					r.Southwest.Latitude = c[1]
+				}
+				if c[0] > r.Northeast.Longitude {
+					r.Northeast.Longitude = c[0]
+				}
+				if c[1] > r.Northeast.Latitude {
+					r.Northeast.Latitude = c[1]
+				}
+			}
+		}
+	}
+	if first {
+		err = fmt.Errorf("Empty geometry")
+	}
+	return
+}
+
+func (p Polygon) Contains(l Location) bool {
+	if len(p) == 0 || !p[0].Contains(l) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.Contains(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains uses the even-odd rule, treating coordinates as planar.
+func (r Ring) Contains(l Location) bool {
+	inside := false
+	x, y := l.Longitude, l.Latitude
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,111 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"math"
+)
+
+type GeoPrivacy string
+
+const (
+	OpenGeoPrivacy     GeoPrivacy = "open"
+	ObscuredGeoPrivacy GeoPrivacy = "obscured"
+	PrivateGeoPrivacy  GeoPrivacy = "private"
+)
+
+// ObscuredCellDegrees is the size of the grid cells obscured coordinates
+// are randomized within.
+const ObscuredCellDegrees = 0.2
+
+func (g GeoPrivacy) Validate() error {
+	switch g {
+	case "", OpenGeoPrivacy, ObscuredGeoPrivacy, PrivateGeoPrivacy:
+		return nil
+	}
+	return fmt.Errorf("Invalid geoprivacy: '%s'", g)
+}
+
+// IsOpen is true for the open setting, which the server sends as null.
+func (g GeoPrivacy) IsOpen() bool {
+	return g == "" || g == OpenGeoPrivacy
+}
+
+// ObscuredCell returns the grid cell an obscured coordinate was placed in.
+// The true location is somewhere in the same cell.
+func ObscuredCell(l Location) Rectangle {
+	south := math.Floor(l.Latitude/ObscuredCellDegrees) * ObscuredCellDegrees
+	west := math.Floor(l.Longitude/ObscuredCellDegrees) * ObscuredCellDegrees
+	return Rectangle{
+		Southwest: Location{Longitude: west, Latitude: south},
+		Northeast: Location{
+			Longitude: math.Min(180, west+ObscuredCellDegrees),
+			Latitude:  math.Min(90, south+ObscuredCellDegrees),
+		},
+	}
+}
+
+type Coordinates struct {
+	Location Location
+	// Accuracy is the positional accuracy in meters, or zero if unknown.
+	Accuracy int32
+	// Exact is set when Location is the true location of the observation.
+	Exact bool
+	// Uncertainty bounds the true location of obscured observations.
+	Uncertainty *Rectangle
+}
+
+func (o *SimpleObservation) HasPrivateCoordinates() bool {
+	return o.PrivateLatitude != 0 || o.PrivateLongitude != 0
+}
+
+func (o *SimpleObservation) HasCoordinates() bool {
+	return o.Latitude != 0 || o.Longitude != 0
+}
+
+// IsObscured is true when the public coordinates aren't the true location,
+// either because of the observer's setting or a threatened taxon.
+func (o *SimpleObservation) IsObscured() bool {
+	return o.Obscured || !o.Geoprivacy.IsOpen() || !o.TaxonGeoprivacy.IsOpen()
+}
+
+// BestCoordinates returns the true coordinates when the caller can see them,
+// otherwise the public ones. For obscured observations the location is the
+// center of the obscuring cell, which is returned as the uncertainty.
+func (o *SimpleObservation) BestCoordinates() (*Coordinates, error) {
+	if o.HasPrivateCoordinates() {
+		return &Coordinates{
+			Location: Location{
+				Longitude: o.PrivateLongitude,
+				Latitude:  o.PrivateLatitude,
+			},
+			Accuracy: o.PositionalAccuracy,
+			Exact:    true,
+		}, nil
+	}
+
+	if !o.HasCoordinates() {
+		return nil, fmt.Errorf("Observation %d has no coordinates", o.Id)
+	}
+
+	public := Location{
+		Longitude: o.Longitude,
+		Latitude:  o.Latitude,
+	}
+
+	if !o.IsObscured() {
+		return &Coordinates{
+			Location: public,
+			Accuracy: o.PositionalAccuracy,
+			Exact:    true,
+		}, nil
+	}
+
+	cell := ObscuredCell(public)
+
+	return &Coordinates{
+		Location:    cell.Center(),
+		Accuracy:    o.PublicPositionalAccuracy,
+		Exact:       false,
+		Uncertainty: &cell,
+	}, nil
+}
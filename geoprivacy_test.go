@@ -0,0 +1,156 @@
+package gonaturalist
+
+import (
+	"math"
+	"testing"
+)
+
+func TestObscuredCell(t *testing.T) {
+	tests := []struct {
+		l        Location
+		expected Rectangle
+	}{
+		{Location{Latitude: 45.53, Longitude: -122.67}, Rectangle{Location{Longitude: -122.8, Latitude: 45.4}, Location{Longitude: -122.6, Latitude: 45.6}}},
+		{Location{Latitude: -33.87, Longitude: 151.21}, Rectangle{Location{Longitude: 151.2, Latitude: -34}, Location{Longitude: 151.4, Latitude: -33.8}}},
+		{Location{Latitude: 0, Longitude: 0}, Rectangle{Location{Longitude: 0, Latitude: 0}, Location{Longitude: 0.2, Latitude: 0.2}}},
+		{Location{Latitude: 89.95, Longitude: 179.95}, Rectangle{Location{Longitude: 179.8, Latitude: 89.8}, Location{Longitude: 180, Latitude: 90}}},
+	}
+
+	for _, test := range tests {
+		cell := ObscuredCell(test.l)
+		if !closeRectangle(cell, test.expected) {
+			t.Errorf("%+v: expected %+v, got %+v", test.l, test.expected, cell)
+		}
+		if !cell.Contains(test.l) {
+			t.Errorf("%+v: expected the cell to contain the location", test.l)
+		}
+	}
+}
+
+func closeRectangle(a, b Rectangle) bool {
+	return closeTo(a.Southwest.Latitude, b.Southwest.Latitude, 1e-9) &&
+		closeTo(a.Southwest.Longitude, b.Southwest.Longitude, 1e-9) &&
+		closeTo(a.Northeast.Latitude, b.Northeast.Latitude, 1e-9) &&
+		closeTo(a.Northeast.Longitude, b.Northeast.Longitude, 1e-9)
+}
+
+func TestBestCoordinates(t *testing.T) {
+	tests := []struct {
+		name        string
+		o           SimpleObservation
+		expected    Location
+		accuracy    int32
+		exact       bool
+		uncertainty *Rectangle
+		fails       bool
+	}{
+		{
+			name: "private coordinates visible to the caller",
+			o: SimpleObservation{
+				Latitude: 45.53, Longitude: -122.67,
+				PrivateLatitude: 45.5123, PrivateLongitude: -122.6587,
+				Geoprivacy: ObscuredGeoPrivacy, PositionalAccuracy: 8, PublicPositionalAccuracy: 28000,
+			},
+			expected: Location{Latitude: 45.5123, Longitude: -122.6587},
+			accuracy: 8,
+			exact:    true,
+		},
+		{
+			name: "open",
+			o: SimpleObservation{
+				Latitude: 45.5123, Longitude: -122.6587, PositionalAccuracy: 8,
+			},
+			expected: Location{Latitude: 45.5123, Longitude: -122.6587},
+			accuracy: 8,
+			exact:    true,
+		},
+		{
+			name: "open set explicitly",
+			o: SimpleObservation{
+				Latitude: 45.5123, Longitude: -122.6587, Geoprivacy: OpenGeoPrivacy, TaxonGeoprivacy: OpenGeoPrivacy,
+			},
+			expected: Location{Latitude: 45.5123, Longitude: -122.6587},
+			exact:    true,
+		},
+		{
+			name: "obscured by the observer",
+			o: SimpleObservation{
+				Latitude: 45.53, Longitude: -122.67, Geoprivacy: ObscuredGeoPrivacy,
+				PositionalAccuracy: 8, PublicPositionalAccuracy: 28000,
+			},
+			expected:    Location{Latitude: 45.5, Longitude: -122.7},
+			accuracy:    28000,
+			uncertainty: &Rectangle{Location{Longitude: -122.8, Latitude: 45.4}, Location{Longitude: -122.6, Latitude: 45.6}},
+		},
+		{
+			name: "obscured for a threatened taxon",
+			o: SimpleObservation{
+				Latitude: 45.53, Longitude: -122.67, TaxonGeoprivacy: ObscuredGeoPrivacy,
+			},
+			expected:    Location{Latitude: 45.5, Longitude: -122.7},
+			uncertainty: &Rectangle{Location{Longitude: -122.8, Latitude: 45.4}, Location{Longitude: -122.6, Latitude: 45.6}},
+		},
+		{
+			name: "obscured flag",
+			o: SimpleObservation{
+				Latitude: 45.53, Longitude: -122.67, Obscured: true,
+			},
+			expected:    Location{Latitude: 45.5, Longitude: -122.7},
+			uncertainty: &Rectangle{Location{Longitude: -122.8, Latitude: 45.4}, Location{Longitude: -122.6, Latitude: 45.6}},
+		},
+		{
+			name: "private without access",
+			o: SimpleObservation{
+				Geoprivacy: PrivateGeoPrivacy,
+			},
+			fails: true,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := test.o.BestCoordinates()
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", test.name, c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if math.Abs(c.Location.Latitude-test.expected.Latitude) > 1e-9 || math.Abs(c.Location.Longitude-test.expected.Longitude) > 1e-9 {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, c.Location)
+		}
+		if c.Accuracy != test.accuracy || c.Exact != test.exact {
+			t.Errorf("%s: expected accuracy %d exact %v, got %d %v", test.name, test.accuracy, test.exact, c.Accuracy, c.Exact)
+		}
+		if (c.Uncertainty == nil) != (test.uncertainty == nil) || (c.Uncertainty != nil && !closeRectangle(*c.Uncertainty, *test.uncertainty)) {
+			t.Errorf("%s: expected uncertainty %+v, got %+v", test.name, test.uncertainty, c.Uncertainty)
+		}
+	}
+}
+
+func TestGeoPrivacyValidate(t *testing.T) {
+	tests := []struct {
+		g     GeoPrivacy
+		valid bool
+		open  bool
+	}{
+		{"", true, true},
+		{OpenGeoPrivacy, true, true},
+		{ObscuredGeoPrivacy, true, false},
+		{PrivateGeoPrivacy, true, false},
+		{"hidden", false, false},
+	}
+
+	for _, test := range tests {
+		if err := test.g.Validate(); (err == nil) != test.valid {
+			t.Errorf("%q: expected valid %v, got %v", test.g, test.valid, err)
+		}
+		if test.valid && test.g.IsOpen() != test.open {
+			t.Errorf("%q: expected open %v", test.g, test.open)
+		}
+	}
+}
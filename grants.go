@@ -0,0 +1,94 @@
+package gonaturalist
+
+import (
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// PasswordToken uses the resource owner password grant, which the server
+// only allows for trusted applications.
+func (a Authenticator) PasswordToken(username string, password string) (*oauth2.Token, error) {
+	token, err := a.config.PasswordCredentialsToken(a.context, username, password)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting password token: %v", err)
+	}
+	return token, nil
+}
+
+func (a *Authenticator) NewClientWithPassword(username string, password string, callbacks Callbacks) (*Client, error) {
+	token, err := a.PasswordToken(username, password)
+	if err != nil {
+		return nil, err
+	}
+	return a.NewClient(token, callbacks), nil
+}
+
+// NewClientWithPasswordAndStore reuses the stored token if it's still
+// usable and only logs in with the password otherwise.
+func (a *Authenticator) NewClientWithPasswordAndStore(username string, password string, store TokenStore, callbacks Callbacks) (*Client, error) {
+	token, err := loadUsableToken(store)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		token, err = a.PasswordToken(username, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a.NewClientWithStore(token, store, callbacks)
+}
+
+func (a Authenticator) clientCredentialsConfig() *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:     a.config.ClientID,
+		ClientSecret: a.config.ClientSecret,
+		TokenURL:     a.config.Endpoint.TokenURL,
+		Scopes:       a.config.Scopes,
+	}
+}
+
+// NewClientWithClientCredentials authenticates as the application itself
+// rather than a user.
+func (a *Authenticator) NewClientWithClientCredentials(callbacks Callbacks) (*Client, error) {
+	source := a.clientCredentialsConfig().TokenSource(a.context)
+	if _, err := source.Token(); err != nil {
+		return nil, fmt.Errorf("Error getting client credentials token: %v", err)
+	}
+	return a.newClientWithSource(source, callbacks), nil
+}
+
+func (a *Authenticator) NewClientWithClientCredentialsAndStore(store TokenStore, callbacks Callbacks) (*Client, error) {
+	token, err := loadUsableToken(store)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &storingTokenSource{
+		source: oauth2.ReuseTokenSource(token, a.clientCredentialsConfig().TokenSource(a.context)),
+		store:  store,
+	}
+	if token != nil {
+		source.last = token.AccessToken
+	}
+	if _, err := source.Token(); err != nil {
+		return nil, fmt.Errorf("Error getting client credentials token: %v", err)
+	}
+
+	return a.newClientWithSource(source, callbacks), nil
+}
+
+// loadUsableToken returns the stored token if it's unexpired or can be
+// refreshed, otherwise nil.
+func loadUsableToken(store TokenStore) (*oauth2.Token, error) {
+	token, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("Loading token: %v", err)
+	}
+	if token == nil || (!token.Valid() && token.RefreshToken == "") {
+		return nil, nil
+	}
+	return token, nil
+}
@@ -2,8 +2,24 @@ package gonaturalist
 
 import (
 	"fmt"
+	"time"
 )
 
+type Identification struct {
+	Id            int64        `json:"id"`
+	Uuid          string       `json:"uuid"`
+	ObservationId int64        `json:"observation_id"`
+	TaxonId       int32        `json:"taxon_id"`
+	UserId        int64        `json:"user_id"`
+	Body          string       `json:"body"`
+	Category      string       `json:"category"`
+	Current       bool         `json:"current"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Taxon         *SimpleTaxon `json:"taxon"`
+	User          *SimpleUser  `json:"user"`
+}
+
 type AddIdentificationOpt struct {
 }
 
@@ -0,0 +1,148 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const (
+	DefaultInteractiveTimeout = 5 * time.Minute
+	DefaultCallbackPath       = "/callback"
+)
+
+type InteractiveOpt struct {
+	// Timeout bounds the wait for the browser to come back, defaults to
+	// DefaultInteractiveTimeout.
+	Timeout time.Duration
+	// Port to listen on, zero picks a random one. The redirect url
+	// http://127.0.0.1:<port><CallbackPath> must be allowed for the app.
+	Port         int
+	CallbackPath string
+	Pkce         bool
+	// Open is called with the authorize url, defaults to OpenBrowser.
+	Open func(url string) error
+	// Out receives the authorize url and progress, defaults to stderr.
+	Out io.Writer
+}
+
+// OpenBrowser tries to open the url with the platform's default browser.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+type callbackResult struct {
+	state string
+	code  string
+	err   error
+}
+
+func (a Authenticator) AuthorizeInteractive(ctx context.Context) (*oauth2.Token, error) {
+	return a.AuthorizeInteractiveWithOpt(ctx, &InteractiveOpt{})
+}
+
+// AuthorizeInteractiveWithOpt runs the authorization code flow for command
+// line tools: it listens on the loopback interface for the redirect, sends
+// the user to the authorize url, then validates the state and exchanges the
+// code.
+func (a Authenticator) AuthorizeInteractiveWithOpt(ctx context.Context, opt *InteractiveOpt) (*oauth2.Token, error) {
+	timeout := opt.Timeout
+	if timeout == 0 {
+		timeout = DefaultInteractiveTimeout
+	}
+	path := opt.CallbackPath
+	if path == "" {
+		path = DefaultCallbackPath
+	}
+	out := opt.Out
+	if out == nil {
+		out = os.Stderr
+	}
+	open := opt.Open
+	if open == nil {
+		open = OpenBrowser
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", opt.Port))
+	if err != nil {
+		return nil, fmt.Errorf("Listening for callback: %v", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	config := *a.config
+	config.RedirectURL = fmt.Sprintf("http://127.0.0.1:%d%s", port, path)
+	a.config = &config
+
+	flow, err := a.BeginAuth(opt.Pkce)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	results := make(chan callbackResult, 1)
+
+	mux := http.NewServeMux()
+	// Only a callback with the state sent ends the flow, anything else
+	// hitting the port is refused so it can't cancel the login.
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		result := callbackResult{
+			state: r.FormValue("state"),
+			code:  r.FormValue("code"),
+		}
+		if err := flow.VerifyState(result.state); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if e := r.FormValue("error"); e != "" {
+			result.err = fmt.Errorf("Authorization failed: %s %s", e, r.FormValue("error_description"))
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintf(w, "Authorized, you may close this window.\n")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(out, "Open %s to authorize.\n", flow.Url)
+	if err := open(flow.Url); err != nil {
+		fmt.Fprintf(out, "Unable to open browser: %v\n", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("Timed out waiting for authorization")
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return a.CompleteAuth(flow, result.state, result.code)
+	}
+}
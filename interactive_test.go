@@ -0,0 +1,112 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestAuthorizeInteractive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/token" || r.FormValue("code") != "the-code" {
+			http.Error(w, "unexpected token request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("code_verifier") == "" {
+			http.Error(w, "missing code_verifier", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"the-token","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+
+	a := NewAuthenticatorAtCustomRoots("id", "secret", "", server.URL, server.URL+"/v1")
+
+	callback := func(redirect string, query url.Values) int {
+		resp, err := http.Get(redirect + "?" + query.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	opt := &InteractiveOpt{
+		Pkce: true,
+		Out:  ioutil.Discard,
+		Open: func(authorizeUrl string) error {
+			u, err := url.Parse(authorizeUrl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q := u.Query()
+			redirect, state := q.Get("redirect_uri"), q.Get("state")
+			if !strings.HasPrefix(redirect, "http://127.0.0.1:") || state == "" || q.Get("code_challenge_method") != "S256" {
+				t.Errorf("unexpected authorize url %s", authorizeUrl)
+			}
+
+			// Stray and forged requests are refused without ending the flow.
+			tests := []struct {
+				query  url.Values
+				status int
+			}{
+				{url.Values{}, http.StatusBadRequest},
+				{url.Values{"code": {"forged"}}, http.StatusBadRequest},
+				{url.Values{"code": {"forged"}, "state": {"wrong"}}, http.StatusBadRequest},
+				{url.Values{"error": {"access_denied"}, "state": {"wrong"}}, http.StatusBadRequest},
+				{url.Values{"code": {"the-code"}, "state": {state}}, http.StatusOK},
+			}
+			for _, test := range tests {
+				if status := callback(redirect, test.query); status != test.status {
+					t.Errorf("%v: expected %d, got %d", test.query, test.status, status)
+				}
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := a.AuthorizeInteractiveWithOpt(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "the-token" {
+		t.Errorf("expected the-token, got %s", token.AccessToken)
+	}
+}
+
+func TestAuthorizeInteractiveDenied(t *testing.T) {
+	a := NewAuthenticatorAtCustomRoots("id", "secret", "", "http://127.0.0.1:1", "http://127.0.0.1:1/v1")
+
+	opt := &InteractiveOpt{
+		Out: ioutil.Discard,
+		Open: func(authorizeUrl string) error {
+			u, _ := url.Parse(authorizeUrl)
+			q := u.Query()
+			resp, err := http.Get(fmt.Sprintf("%s?error=access_denied&state=%s", q.Get("redirect_uri"), q.Get("state")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := a.AuthorizeInteractiveWithOpt(ctx, opt)
+	if err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Errorf("expected the denial to end the flow, got %v", err)
+	}
+}
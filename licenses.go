@@ -0,0 +1,163 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// License is how a photo, sound or observation may be reused, as the
+// lowercase code the node API uses.
+type License string
+
+const (
+	AllRightsReserved License = "c"
+	CC0               License = "cc0"
+	CCBy              License = "cc-by"
+	CCBySa            License = "cc-by-sa"
+	CCByNd            License = "cc-by-nd"
+	CCByNc            License = "cc-by-nc"
+	CCByNcSa          License = "cc-by-nc-sa"
+	CCByNcNd          License = "cc-by-nc-nd"
+	PublicDomain      License = "pd"
+	GFDL              License = "gfdl"
+)
+
+// railsPhotoLicenses are the numbers the Rails API uses for photo licenses.
+var railsPhotoLicenses = []License{
+	AllRightsReserved,
+	CCByNcSa,
+	CCByNc,
+	CCByNcNd,
+	CCBy,
+	CCBySa,
+	CCByNd,
+	PublicDomain,
+	GFDL,
+	CC0,
+}
+
+var licenseNames = map[License]string{
+	AllRightsReserved: "All rights reserved",
+	CC0:               "CC0",
+	CCBy:              "CC BY",
+	CCBySa:            "CC BY-SA",
+	CCByNd:            "CC BY-ND",
+	CCByNc:            "CC BY-NC",
+	CCByNcSa:          "CC BY-NC-SA",
+	CCByNcNd:          "CC BY-NC-ND",
+	PublicDomain:      "Public domain",
+	GFDL:              "GNU FDL",
+}
+
+// ParseLicense accepts codes in either case, "CC-BY-NC" or "cc-by-nc",
+// and treats an empty code as all rights reserved, as the server does.
+func ParseLicense(code string) (License, error) {
+	l := License(strings.ToLower(strings.TrimSpace(code)))
+	switch l {
+	case "", "none", "all rights reserved":
+		return AllRightsReserved, nil
+	}
+	if _, ok := licenseNames[l]; !ok {
+		return "", fmt.Errorf("Unknown license: '%s'", code)
+	}
+	return l, nil
+}
+
+func (l License) String() string {
+	if name, ok := licenseNames[l]; ok {
+		return name
+	}
+	return string(l)
+}
+
+func (l License) Validate() error {
+	if _, ok := licenseNames[l]; !ok {
+		return fmt.Errorf("Unknown license: '%s'", l)
+	}
+	return nil
+}
+
+func (l License) IsCreativeCommons() bool {
+	return strings.HasPrefix(string(l), "cc")
+}
+
+func (l License) AllowsCommercialUse() bool {
+	switch l {
+	case CC0, CCBy, CCBySa, CCByNd, PublicDomain, GFDL:
+		return true
+	}
+	return false
+}
+
+func (l License) AllowsDerivatives() bool {
+	switch l {
+	case CC0, CCBy, CCBySa, CCByNc, CCByNcSa, PublicDomain, GFDL:
+		return true
+	}
+	return false
+}
+
+// RequiresAttribution is true for anything but CC0 and public domain,
+// including all rights reserved, which needs permission as well.
+func (l License) RequiresAttribution() bool {
+	return l != CC0 && l != PublicDomain
+}
+
+// Url is the license's deed, iNaturalist uses version 4.0 of the Creative
+// Commons licenses.
+func (l License) Url() string {
+	switch l {
+	case CC0:
+		return "https://creativecommons.org/publicdomain/zero/1.0/"
+	case PublicDomain:
+		return "https://creativecommons.org/publicdomain/mark/1.0/"
+	case GFDL:
+		return "https://www.gnu.org/copyleft/fdl.html"
+	}
+	if l.IsCreativeCommons() {
+		return fmt.Sprintf("https://creativecommons.org/licenses/%s/4.0/", strings.TrimPrefix(string(l), "cc-"))
+	}
+	return ""
+}
+
+// searchValue is the license as the Rails search expects it.
+func (l License) searchValue() string {
+	if l == AllRightsReserved {
+		return "none"
+	}
+	return strings.ToUpper(string(l))
+}
+
+// UnmarshalJSON accepts codes, null for all rights reserved and the
+// numbers the Rails API uses for photos.
+func (l *License) UnmarshalJSON(b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		*l = AllRightsReserved
+	case float64:
+		i := int(typed)
+		if float64(i) != typed || i < 0 || i >= len(railsPhotoLicenses) {
+			return fmt.Errorf("Unknown license: %v", typed)
+		}
+		*l = railsPhotoLicenses[i]
+	case string:
+		parsed, err := ParseLicense(typed)
+		if err != nil {
+			// Keep codes this version doesn't know about rather than
+			// failing to decode the whole observation.
+			*l = License(strings.ToLower(typed))
+			return nil
+		}
+		*l = parsed
+	default:
+		return fmt.Errorf("Unexpected license: %s", b)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLicenseUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected License
+		fails    bool
+	}{
+		{`null`, AllRightsReserved, false},
+		{`0`, AllRightsReserved, false},
+		{`1`, CCByNcSa, false},
+		{`2`, CCByNc, false},
+		{`3`, CCByNcNd, false},
+		{`4`, CCBy, false},
+		{`5`, CCBySa, false},
+		{`6`, CCByNd, false},
+		{`7`, PublicDomain, false},
+		{`8`, GFDL, false},
+		{`9`, CC0, false},
+		{`10`, "", true},
+		{`-1`, "", true},
+		{`1.5`, "", true},
+		{`"cc-by-nc"`, CCByNc, false},
+		{`"CC-BY-NC"`, CCByNc, false},
+		{`"cc0"`, CC0, false},
+		{`""`, AllRightsReserved, false},
+		{`"none"`, AllRightsReserved, false},
+		{`"CC-BY-4.1"`, License("cc-by-4.1"), false},
+		{`true`, "", true},
+		{`{}`, "", true},
+	}
+
+	for _, test := range tests {
+		var l License
+		err := json.Unmarshal([]byte(test.json), &l)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", test.json, l)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.json, err)
+			continue
+		}
+		if l != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.json, test.expected, l)
+		}
+	}
+}
+
+func TestLicenseDecodesInPhotos(t *testing.T) {
+	var photos []struct {
+		License     License `json:"license"`
+		LicenseCode License `json:"license_code"`
+	}
+	body := `[{"license":4,"license_code":"cc-by"},{"license":0,"license_code":null}]`
+	if err := json.Unmarshal([]byte(body), &photos); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range photos {
+		if p.License != p.LicenseCode {
+			t.Errorf("photo %d: Rails number %q and code %q differ", i, p.License, p.LicenseCode)
+		}
+	}
+}
+
+func TestParseLicense(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected License
+		fails    bool
+	}{
+		{"CC-BY-SA", CCBySa, false},
+		{" cc-by-nd ", CCByNd, false},
+		{"", AllRightsReserved, false},
+		{"All rights reserved", AllRightsReserved, false},
+		{"pd", PublicDomain, false},
+		{"cc-by-xx", "", true},
+	}
+
+	for _, test := range tests {
+		l, err := ParseLicense(test.code)
+		if (err != nil) != test.fails {
+			t.Errorf("%q: unexpected error %v", test.code, err)
+			continue
+		}
+		if l != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.code, test.expected, l)
+		}
+	}
+}
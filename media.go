@@ -0,0 +1,403 @@
+package gonaturalist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type PhotoSize string
+
+const (
+	SquarePhoto   PhotoSize = "square"
+	ThumbPhoto    PhotoSize = "thumb"
+	SmallPhoto    PhotoSize = "small"
+	MediumPhoto   PhotoSize = "medium"
+	LargePhoto    PhotoSize = "large"
+	OriginalPhoto PhotoSize = "original"
+)
+
+var photoSizes = map[PhotoSize]bool{
+	SquarePhoto:   true,
+	ThumbPhoto:    true,
+	SmallPhoto:    true,
+	MediumPhoto:   true,
+	LargePhoto:    true,
+	OriginalPhoto: true,
+}
+
+func (s PhotoSize) Validate() error {
+	if !photoSizes[s] {
+		return fmt.Errorf("Invalid photo size: '%s'", s)
+	}
+	return nil
+}
+
+// PhotoSizeUrl swaps the size in a photo url, they all look like
+// .../photos/1234/medium.jpg?1545345 with only the name of the file
+// changing between sizes.
+func PhotoSizeUrl(photoUrl string, size PhotoSize) (string, error) {
+	u, err := url.Parse(photoUrl)
+	if err != nil {
+		return "", err
+	}
+
+	dir, file := path.Split(u.Path)
+	dot := strings.LastIndex(file, ".")
+	if dot < 0 || !photoSizes[PhotoSize(file[:dot])] {
+		return "", fmt.Errorf("Unrecognized photo url: '%s'", photoUrl)
+	}
+
+	u.Path = dir + string(size) + file[dot:]
+	return u.String(), nil
+}
+
+// SizeUrl returns the url of the given size, deriving it from one of the
+// others when the server didn't include it.
+func (p *SimplePhoto) SizeUrl(size PhotoSize) (string, error) {
+	known := map[PhotoSize]string{
+		SquarePhoto:   p.SquareUrl,
+		ThumbPhoto:    p.ThumbUrl,
+		SmallPhoto:    p.SmallUrl,
+		MediumPhoto:   p.MediumUrl,
+		LargePhoto:    p.LargeUrl,
+		OriginalPhoto: p.OriginalUrl,
+	}
+	if known[size] != "" {
+		return known[size], nil
+	}
+
+	for _, other := range []string{p.Url, p.SquareUrl, p.ThumbUrl, p.SmallUrl, p.MediumUrl, p.LargeUrl, p.OriginalUrl} {
+		if other != "" {
+			return PhotoSizeUrl(other, size)
+		}
+	}
+
+	return "", fmt.Errorf("Photo %d has no urls", p.Id)
+}
+
+type DownloadPhotosOpt struct {
+	// Directory is the root of the archive, see DownloadedPhoto.
+	Directory string
+	// Sizes defaults to original.
+	Sizes   []PhotoSize
+	Workers int
+	// Http is used for the downloads instead of the client's own, which
+	// would send the access token to the photo hosts. Defaults to
+	// http.DefaultClient.
+	Http *http.Client
+}
+
+// DownloadedPhoto is kept as JSON next to each download, in
+// photos/<photo id>/<size>.json under the archive directory. The image
+// itself is stored by the SHA-256 of its contents in
+// objects/<first two hex digits>/<hash>.<extension>, Path being relative
+// to the archive directory.
+type DownloadedPhoto struct {
+	PhotoId       int64     `json:"photo_id"`
+	ObservationId int64     `json:"observation_id"`
+	Size          PhotoSize `json:"size"`
+	Url           string    `json:"url"`
+	Sha256        string    `json:"sha256"`
+	Path          string    `json:"path"`
+	ContentType   string    `json:"content_type"`
+	Bytes         int64     `json:"bytes"`
+	License       License   `json:"license_code"`
+	LicenseUrl    string    `json:"license_url,omitempty"`
+	Attribution   string    `json:"attribution"`
+	DownloadedAt  time.Time `json:"downloaded_at"`
+	// Skipped is true when the photo was already in the archive.
+	Skipped bool `json:"-"`
+}
+
+type photoDownload struct {
+	observation *FullObservation
+	photo       *SimplePhoto
+	size        PhotoSize
+}
+
+func sidecarPath(directory string, photoId int64, size PhotoSize) string {
+	return filepath.Join(directory, "photos", fmt.Sprintf("%d", photoId), string(size)+".json")
+}
+
+func readSidecar(directory string, photoId int64, size PhotoSize) *DownloadedPhoto {
+	data, err := ioutil.ReadFile(sidecarPath(directory, photoId, size))
+	if err != nil {
+		return nil
+	}
+
+	existing := &DownloadedPhoto{}
+	if err := json.Unmarshal(data, existing); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(directory, filepath.FromSlash(existing.Path))); err != nil {
+		return nil
+	}
+
+	existing.Skipped = true
+	return existing
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func imageExtension(contentType string, photoUrl string) string {
+	if ext, ok := imageExtensions[contentType]; ok {
+		return ext
+	}
+	if u, err := url.Parse(photoUrl); err == nil {
+		if ext := path.Ext(u.Path); ext != "" {
+			return strings.ToLower(ext)
+		}
+	}
+	return ".img"
+}
+
+func (c *Client) downloadPhoto(ctx context.Context, hc *http.Client, directory string, d *photoDownload) (*DownloadedPhoto, error) {
+	if existing := readSidecar(directory, d.photo.Id, d.size); existing != nil {
+		return existing, nil
+	}
+
+	photoUrl, err := d.photo.SizeUrl(d.size)
+	if err != nil {
+		return nil, err
+	}
+
+	t := c.startRequest("GET", photoUrl)
+
+	req, err := http.NewRequest("GET", photoUrl, nil)
+	if err != nil {
+		t.completed(0, 0, nil, false, err)
+		return nil, err
+	}
+
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		t.completed(0, 0, nil, false, err)
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("Downloading %s: %s", photoUrl, resp.Status)
+		t.completed(resp.StatusCode, 0, nil, false, err)
+		return nil, err
+	}
+
+	declared, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	tmp, err := ioutil.TempFile(filepath.Join(directory, "tmp"), "photo")
+	if err != nil {
+		t.completed(resp.StatusCode, 0, nil, false, err)
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	sniff := &prefixWriter{limit: 512}
+	body := &countingReader{r: resp.Body}
+	_, err = io.Copy(io.MultiWriter(tmp, hash, sniff), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.completed(resp.StatusCode, body.n, nil, false, err)
+		return nil, err
+	}
+
+	// Trust what the bytes are over the header, but both have to say
+	// image, an html error page served with a 200 is a common failure.
+	sniffed := http.DetectContentType(sniff.data)
+	if !strings.HasPrefix(declared, "image/") || !strings.HasPrefix(sniffed, "image/") {
+		err := fmt.Errorf("Downloading %s: expected an image, got %s (%s)", photoUrl, declared, sniffed)
+		t.completed(resp.StatusCode, body.n, nil, false, err)
+		return nil, err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	relative := path.Join("objects", sum[:2], sum+imageExtension(sniffed, photoUrl))
+	object := filepath.Join(directory, filepath.FromSlash(relative))
+
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		t.completed(resp.StatusCode, body.n, nil, false, err)
+		return nil, err
+	}
+	if _, err := os.Stat(object); os.IsNotExist(err) {
+		if err := os.Rename(tmp.Name(), object); err != nil {
+			t.completed(resp.StatusCode, body.n, nil, false, err)
+			return nil, err
+		}
+	}
+
+	downloaded := &DownloadedPhoto{
+		PhotoId:       d.photo.Id,
+		ObservationId: d.observation.Id,
+		Size:          d.size,
+		Url:           photoUrl,
+		Sha256:        sum,
+		Path:          relative,
+		ContentType:   sniffed,
+		Bytes:         body.n,
+		License:       d.photo.License,
+		LicenseUrl:    d.photo.License.Url(),
+		Attribution:   d.photo.Attribution,
+		DownloadedAt:  time.Now().UTC(),
+	}
+
+	if err := writeSidecar(directory, downloaded); err != nil {
+		t.completed(resp.StatusCode, body.n, nil, false, err)
+		return nil, err
+	}
+
+	t.completed(resp.StatusCode, body.n, nil, false, nil)
+
+	return downloaded, nil
+}
+
+// writeSidecar is written last, and atomically, so a sidecar only exists
+// for photos that were completely downloaded.
+func writeSidecar(directory string, downloaded *DownloadedPhoto) error {
+	name := sidecarPath(directory, downloaded.PhotoId, downloaded.Size)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(downloaded, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "sidecar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+type prefixWriter struct {
+	limit int
+	data  []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if remaining := w.limit - len(w.data); remaining > 0 {
+		if len(p) < remaining {
+			remaining = len(p)
+		}
+		w.data = append(w.data, p[:remaining]...)
+	}
+	return len(p), nil
+}
+
+// DownloadPhotos saves the photos of the observations into an archive
+// directory, see DownloadedPhoto for the layout. Photos already in the
+// archive are skipped, so an interrupted download can be resumed. The
+// first error cancels the remaining downloads.
+func (c *Client) DownloadPhotos(ctx context.Context, observations []*FullObservation, opt *DownloadPhotosOpt) ([]*DownloadedPhoto, error) {
+	sizes := opt.Sizes
+	if len(sizes) == 0 {
+		sizes = []PhotoSize{OriginalPhoto}
+	}
+	for _, size := range sizes {
+		if err := size.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	workers := opt.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	hc := opt.Http
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	if err := os.MkdirAll(filepath.Join(opt.Directory, "tmp"), 0755); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	downloads := make(chan *photoDownload)
+	go func() {
+		defer close(downloads)
+		for _, o := range observations {
+			for _, op := range o.Photos {
+				for _, size := range sizes {
+					select {
+					case downloads <- &photoDownload{observation: o, photo: &op.Photo, size: size}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var lock sync.Mutex
+	var firstErr error
+	downloaded := make([]*DownloadedPhoto, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range downloads {
+				photo, err := c.downloadPhoto(ctx, hc, opt.Directory, d)
+
+				lock.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("Photo %d of observation %d: %v", d.photo.Id, d.observation.Id, err)
+					}
+					cancel()
+				} else {
+					downloaded = append(downloaded, photo)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return downloaded, nil
+}
@@ -0,0 +1,226 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func testPng(t *testing.T) []byte {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestPhotoSizeUrl(t *testing.T) {
+	tests := []struct {
+		url      string
+		size     PhotoSize
+		expected string
+		fails    bool
+	}{
+		{"https://static.inaturalist.org/photos/1234/medium.jpg?1545345", OriginalPhoto, "https://static.inaturalist.org/photos/1234/original.jpg?1545345", false},
+		{"https://static.inaturalist.org/photos/1234/square.png", LargePhoto, "https://static.inaturalist.org/photos/1234/large.png", false},
+		{"https://static.inaturalist.org/photos/1234/photo.jpg", LargePhoto, "", true},
+		{"https://static.inaturalist.org/photos/1234/medium", LargePhoto, "", true},
+	}
+
+	for _, test := range tests {
+		actual, err := PhotoSizeUrl(test.url, test.size)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: unexpected error %v", test.url, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.url, test.expected, actual)
+		}
+	}
+}
+
+func TestDownloadPhotos(t *testing.T) {
+	image := testPng(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/photos/1/original.png", "/photos/2/original.png", "/photos/1/small.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(image)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "gonaturalist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	observations := []*FullObservation{
+		{
+			SimpleObservation: SimpleObservation{Id: 10},
+			Photos: []*ObservationPhoto{
+				{Photo: SimplePhoto{Id: 1, MediumUrl: server.URL + "/photos/1/medium.png", License: CCBy, Attribution: "(c) jacob, some rights reserved (CC BY)"}},
+				{Photo: SimplePhoto{Id: 2, OriginalUrl: server.URL + "/photos/2/original.png", License: AllRightsReserved, Attribution: "(c) jacob, all rights reserved"}},
+			},
+		},
+	}
+
+	c, api := newTestClient(t, http.NotFoundHandler())
+	defer api.Close()
+
+	opt := &DownloadPhotosOpt{
+		Directory: dir,
+		Sizes:     []PhotoSize{OriginalPhoto},
+		Workers:   2,
+	}
+
+	downloaded, err := c.DownloadPhotos(context.Background(), observations, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(downloaded) != 2 || atomic.LoadInt32(&requests) != 2 {
+		t.Fatalf("expected two downloads, got %d with %d requests", len(downloaded), requests)
+	}
+
+	sum := sha256.Sum256(image)
+	hash := hex.EncodeToString(sum[:])
+	object := filepath.Join(dir, "objects", hash[:2], hash+".png")
+	data, err := ioutil.ReadFile(object)
+	if err != nil || !bytes.Equal(data, image) {
+		t.Errorf("expected the image stored by its hash at %s, got %v", object, err)
+	}
+
+	tests := []struct {
+		photoId     int64
+		url         string
+		license     License
+		licenseUrl  string
+		attribution string
+	}{
+		{1, server.URL + "/photos/1/original.png", CCBy, "https://creativecommons.org/licenses/by/4.0/", "(c) jacob, some rights reserved (CC BY)"},
+		{2, server.URL + "/photos/2/original.png", AllRightsReserved, "", "(c) jacob, all rights reserved"},
+	}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(sidecarPath(dir, test.photoId, OriginalPhoto))
+		if err != nil {
+			t.Errorf("photo %d: %v", test.photoId, err)
+			continue
+		}
+		var sidecar DownloadedPhoto
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			t.Errorf("photo %d: %v", test.photoId, err)
+			continue
+		}
+
+		if sidecar.PhotoId != test.photoId || sidecar.ObservationId != 10 || sidecar.Size != OriginalPhoto || sidecar.Url != test.url {
+			t.Errorf("photo %d: unexpected sidecar %s", test.photoId, data)
+		}
+		if sidecar.Sha256 != hash || sidecar.Path != "objects/"+hash[:2]+"/"+hash+".png" || sidecar.ContentType != "image/png" || sidecar.Bytes != int64(len(image)) {
+			t.Errorf("photo %d: unexpected object in sidecar %s", test.photoId, data)
+		}
+		if sidecar.License != test.license || sidecar.LicenseUrl != test.licenseUrl || sidecar.Attribution != test.attribution {
+			t.Errorf("photo %d: unexpected license in sidecar %s", test.photoId, data)
+		}
+		if sidecar.DownloadedAt.IsZero() {
+			t.Errorf("photo %d: expected the download time", test.photoId)
+		}
+	}
+
+	// A second run skips what's already in the archive and only fetches
+	// the new size.
+	opt.Sizes = []PhotoSize{OriginalPhoto, SmallPhoto}
+	observations[0].Photos = observations[0].Photos[:1]
+	again, err := c.DownloadPhotos(context.Background(), observations, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := 0
+	for _, d := range again {
+		if d.Skipped {
+			skipped++
+		}
+	}
+	if len(again) != 2 || skipped != 1 || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("expected one skipped and one new download, got %d skipped of %d with %d requests", skipped, len(again), requests)
+	}
+
+	entries, _ := ioutil.ReadDir(filepath.Join(dir, "tmp"))
+	if len(entries) != 0 {
+		t.Errorf("expected no temporary files left, got %d", len(entries))
+	}
+}
+
+func TestDownloadPhotosRejectsNonImages(t *testing.T) {
+	image := testPng(t)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		status      int
+	}{
+		{"html error page", "text/html", []byte("<!DOCTYPE html><html><body>Oops</body></html>"), http.StatusOK},
+		{"html claiming to be an image", "image/jpeg", []byte("<!DOCTYPE html><html><body>Oops</body></html>"), http.StatusOK},
+		{"image with the wrong type", "application/octet-stream", image, http.StatusOK},
+		{"not found", "image/png", image, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", test.contentType)
+			w.WriteHeader(test.status)
+			w.Write(test.body)
+		}))
+
+		dir, err := ioutil.TempDir("", "gonaturalist")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		observations := []*FullObservation{
+			{
+				SimpleObservation: SimpleObservation{Id: 10},
+				Photos: []*ObservationPhoto{
+					{Photo: SimplePhoto{Id: 1, OriginalUrl: server.URL + "/photos/1/original.jpg"}},
+				},
+			},
+		}
+
+		c, api := newTestClient(t, http.NotFoundHandler())
+		_, err = c.DownloadPhotos(context.Background(), observations, &DownloadPhotosOpt{Directory: dir})
+		if err == nil || !strings.Contains(err.Error(), "Photo 1 of observation 10") {
+			t.Errorf("%s: expected an error naming the photo, got %v", test.name, err)
+		}
+
+		if _, err := os.Stat(sidecarPath(dir, 1, OriginalPhoto)); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no sidecar", test.name)
+		}
+		if objects, _ := filepath.Glob(filepath.Join(dir, "objects", "*", "*")); len(objects) != 0 {
+			t.Errorf("%s: expected nothing archived, got %v", test.name, objects)
+		}
+
+		api.Close()
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
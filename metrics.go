@@ -0,0 +1,236 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type metricKey struct {
+	method   string
+	endpoint string
+	status   string
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// MetricsObserver counts requests, bytes, retries and rate limit waits and
+// records latency histograms per endpoint template. WriteTo renders them in
+// the Prometheus text format and the observer can be served directly as a
+// /metrics handler.
+type MetricsObserver struct {
+	lock        sync.Mutex
+	namespace   string
+	bounds      []float64
+	requests    map[metricKey]uint64
+	cacheHits   map[metricKey]uint64
+	bytes       map[metricKey]uint64
+	retries     map[metricKey]uint64
+	waits       map[metricKey]uint64
+	waitSeconds map[metricKey]float64
+	latencies   map[metricKey]*histogram
+	inFlight    int64
+}
+
+func NewMetricsObserver(namespace string) *MetricsObserver {
+	return NewMetricsObserverWithBuckets(namespace, DefaultLatencyBuckets)
+}
+
+func NewMetricsObserverWithBuckets(namespace string, bounds []float64) *MetricsObserver {
+	sorted := append([]float64{}, bounds...)
+	sort.Float64s(sorted)
+	return &MetricsObserver{
+		namespace:   namespace,
+		bounds:      sorted,
+		requests:    make(map[metricKey]uint64),
+		cacheHits:   make(map[metricKey]uint64),
+		bytes:       make(map[metricKey]uint64),
+		retries:     make(map[metricKey]uint64),
+		waits:       make(map[metricKey]uint64),
+		waitSeconds: make(map[metricKey]float64),
+		latencies:   make(map[metricKey]*histogram),
+	}
+}
+
+func (m *MetricsObserver) RequestStarted(e *RequestEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.inFlight++
+}
+
+func (m *MetricsObserver) ResponseReceived(e *ResponseEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.inFlight--
+
+	status := "error"
+	if e.StatusCode != 0 {
+		status = strconv.Itoa(e.StatusCode)
+	}
+
+	key := metricKey{method: e.Request.Method, endpoint: e.Request.Endpoint, status: status}
+	m.requests[key]++
+	if e.Cached {
+		m.cacheHits[key]++
+	}
+
+	endpoint := metricKey{method: e.Request.Method, endpoint: e.Request.Endpoint}
+	m.bytes[endpoint] += uint64(e.Bytes)
+
+	h, ok := m.latencies[endpoint]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(m.bounds))}
+		m.latencies[endpoint] = h
+	}
+	seconds := e.Elapsed.Seconds()
+	for i, bound := range m.bounds {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *MetricsObserver) Retrying(e *RetryEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.retries[metricKey{method: e.Request.Method, endpoint: e.Request.Endpoint}]++
+}
+
+func (m *MetricsObserver) RateLimited(e *RateLimitEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := metricKey{method: e.Request.Method, endpoint: e.Request.Endpoint}
+	m.waits[key]++
+	m.waitSeconds[key] += e.Wait.Seconds()
+}
+
+func escapeLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+func (k metricKey) labels(extra ...string) string {
+	pairs := []string{
+		fmt.Sprintf(`method="%s"`, escapeLabel(k.method)),
+		fmt.Sprintf(`endpoint="%s"`, escapeLabel(k.endpoint)),
+	}
+	if k.status != "" {
+		pairs = append(pairs, fmt.Sprintf(`status="%s"`, k.status))
+	}
+	pairs = append(pairs, extra...)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func sortedKeys(keys []metricKey) []metricKey {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	return keys
+}
+
+func writeCounter(w io.Writer, name string, help string, values map[metricKey]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]metricKey, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	for _, k := range sortedKeys(keys) {
+		fmt.Fprintf(w, "%s%s %d\n", name, k.labels(), values[k])
+	}
+}
+
+func (m *MetricsObserver) name(metric string) string {
+	if m.namespace == "" {
+		return metric
+	}
+	return m.namespace + "_" + metric
+}
+
+func (m *MetricsObserver) WriteTo(w io.Writer) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cw := &countingWriter{w: w}
+
+	writeCounter(cw, m.name("requests_total"), "Requests completed.", m.requests)
+	writeCounter(cw, m.name("cache_hits_total"), "Requests answered from the cache.", m.cacheHits)
+	writeCounter(cw, m.name("response_bytes_total"), "Response body bytes read.", m.bytes)
+	writeCounter(cw, m.name("retries_total"), "Requests retried.", m.retries)
+	writeCounter(cw, m.name("rate_limit_waits_total"), "Waits caused by rate limiting.", m.waits)
+
+	name := m.name("rate_limit_wait_seconds_total")
+	fmt.Fprintf(cw, "# HELP %s Time spent waiting for rate limits.\n# TYPE %s counter\n", name, name)
+	keys := make([]metricKey, 0, len(m.waitSeconds))
+	for k := range m.waitSeconds {
+		keys = append(keys, k)
+	}
+	for _, k := range sortedKeys(keys) {
+		fmt.Fprintf(cw, "%s%s %g\n", name, k.labels(), m.waitSeconds[k])
+	}
+
+	name = m.name("requests_in_flight")
+	fmt.Fprintf(cw, "# HELP %s Requests started and not yet completed.\n# TYPE %s gauge\n%s %d\n", name, name, name, m.inFlight)
+
+	name = m.name("request_duration_seconds")
+	fmt.Fprintf(cw, "# HELP %s Request latency including retries.\n# TYPE %s histogram\n", name, name)
+	keys = make([]metricKey, 0, len(m.latencies))
+	for k := range m.latencies {
+		keys = append(keys, k)
+	}
+	for _, k := range sortedKeys(keys) {
+		h := m.latencies[k]
+		for i, bound := range m.bounds {
+			fmt.Fprintf(cw, "%s_bucket%s %d\n", name, k.labels(fmt.Sprintf(`le="%g"`, bound)), h.buckets[i])
+		}
+		fmt.Fprintf(cw, "%s_bucket%s %d\n", name, k.labels(`le="+Inf"`), h.count)
+		fmt.Fprintf(cw, "%s_sum%s %g\n", name, k.labels(), h.sum)
+		fmt.Fprintf(cw, "%s_count%s %d\n", name, k.labels(), h.count)
+	}
+
+	return cw.n, cw.err
+}
+
+func (m *MetricsObserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
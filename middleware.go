@@ -0,0 +1,336 @@
+package gonaturalist
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Middleware wraps the transport beneath the client. Middlewares run
+// after the access token has been added, so they see requests as they go
+// out on the wire.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use adds middlewares to the client, the first one given being the
+// outermost. It should be called before the client is used.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+
+	transport := c.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+
+	c.http = &http.Client{
+		Transport: &oauth2.Transport{
+			Source: c.tokens,
+			Base:   transport,
+		},
+	}
+}
+
+// RoundTrip requests never modify the original, as http.RoundTripper
+// requires, so headers are always set on a copy.
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		clone.Header[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+func UserAgent(agent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = cloneRequest(req)
+			req.Header.Set("User-Agent", agent)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+const RequestIdHeader = "X-Request-Id"
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// RequestId gives every request a random X-Request-Id header unless one is
+// already set.
+func RequestId() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(RequestIdHeader) != "" {
+				return next.RoundTrip(req)
+			}
+			req = cloneRequest(req)
+			req.Header.Set(RequestIdHeader, newRequestId())
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+var redactedParameters = []string{
+	"access_token",
+	"client_secret",
+	"password",
+	"code",
+}
+
+const redacted = "REDACTED"
+
+func redactHeaders(h http.Header, names map[string]bool) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		value := strings.Join(h[k], ", ")
+		if names[http.CanonicalHeaderKey(k)] {
+			value = redacted
+		}
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k, value))
+	}
+	return strings.Join(pairs, "; ")
+}
+
+func redactUrl(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	query := u.Query()
+	for _, name := range redactedParameters {
+		if _, ok := query[name]; ok {
+			query.Set(name, redacted)
+		}
+	}
+	redactedUrl := *u
+	redactedUrl.RawQuery = query.Encode()
+	return redactedUrl.String()
+}
+
+// Logging logs every request and response with their headers. The
+// DefaultRedactedHeaders and any extra headers given are replaced with
+// REDACTED, as are credentials in query strings.
+func Logging(logger *log.Logger, extraRedacted ...string) Middleware {
+	names := make(map[string]bool)
+	for _, name := range append(DefaultRedactedHeaders, extraRedacted...) {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			started := time.Now()
+			logger.Printf("%s %s [%s]", req.Method, redactUrl(req.URL), redactHeaders(req.Header, names))
+
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(started)
+			if err != nil {
+				logger.Printf("%s %s failed after %v: %v", req.Method, redactUrl(req.URL), elapsed, err)
+				return nil, err
+			}
+
+			logger.Printf("%s %s %d in %v [%s]", req.Method, redactUrl(req.URL), resp.StatusCode, elapsed, redactHeaders(resp.Header, names))
+
+			return resp, nil
+		})
+	}
+}
+
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		reader, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, err
+		}
+		b.reader = reader
+	}
+	return b.reader.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
+
+// Gzip asks for compressed responses and decompresses them. The standard
+// transport already does this unless the request sets Accept-Encoding
+// itself, this middleware is for transports that don't, and it lets
+// middlewares beneath it see the compressed sizes.
+func Gzip() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+				return next.RoundTrip(req)
+			}
+
+			req = cloneRequest(req)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && req.Method != "HEAD" {
+				resp.Body = &gzipBody{body: resp.Body}
+				resp.Header.Del("Content-Encoding")
+				resp.Header.Del("Content-Length")
+				resp.ContentLength = -1
+				resp.Uncompressed = true
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+var ErrCircuitOpen = errors.New("Circuit open after repeated server errors")
+
+type circuitState struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// CircuitBreaker stops sending requests to a host once it has answered
+// with threshold consecutive 5xx responses, failing them immediately with
+// ErrCircuitOpen. After cooldown a single request is let through, and if
+// it succeeds the circuit closes again. Requests that fail without a
+// response, cancelled or timed out ones included, aren't counted.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	lock      sync.Mutex
+	hosts     map[string]*circuitState
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("Invalid circuit breaker threshold: %d", threshold)
+	}
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		hosts:     make(map[string]*circuitState),
+	}, nil
+}
+
+func (cb *CircuitBreaker) state(host string) *circuitState {
+	s, ok := cb.hosts[host]
+	if !ok {
+		s = &circuitState{}
+		cb.hosts[host] = s
+	}
+	return s
+}
+
+func (cb *CircuitBreaker) allow(host string) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	s := cb.state(host)
+	if s.failures < cb.Threshold {
+		return true
+	}
+	if s.probing || time.Since(s.openedAt) < cb.Cooldown {
+		return false
+	}
+	s.probing = true
+	return true
+}
+
+func (cb *CircuitBreaker) record(host string, failed bool) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	s := cb.state(host)
+	s.probing = false
+	if !failed {
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.failures >= cb.Threshold {
+		s.openedAt = time.Now()
+	}
+}
+
+// release lets another probe through after one ended without a response.
+func (cb *CircuitBreaker) release(host string) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.state(host).probing = false
+}
+
+// Open is true while requests to host are being refused.
+func (cb *CircuitBreaker) Open(host string) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	s := cb.state(host)
+	return s.failures >= cb.Threshold && time.Since(s.openedAt) < cb.Cooldown
+}
+
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+			if !cb.allow(host) {
+				return nil, ErrCircuitOpen
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				cb.release(host)
+				return nil, err
+			}
+
+			cb.record(host, resp.StatusCode >= 500)
+
+			return resp, nil
+		})
+	}
+}
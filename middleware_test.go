@@ -0,0 +1,369 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func respondWith(status int) RoundTripperFunc {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+}
+
+func TestNewCircuitBreakerRejectsThreshold(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		if _, err := NewCircuitBreaker(threshold, time.Minute); err == nil {
+			t.Errorf("expected threshold %d to be rejected", threshold)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	timeout := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, context.DeadlineExceeded
+	})
+	cancelled := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, context.Canceled
+	})
+
+	type step struct {
+		next http.RoundTripper
+		wait bool
+		open bool
+		err  error
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after consecutive server errors",
+			steps: []step{
+				{next: respondWith(500)},
+				{next: respondWith(502)},
+				{next: respondWith(503), open: true},
+				{next: respondWith(200), open: true, err: ErrCircuitOpen},
+			},
+		},
+		{
+			name: "success resets the count",
+			steps: []step{
+				{next: respondWith(500)},
+				{next: respondWith(500)},
+				{next: respondWith(200)},
+				{next: respondWith(500)},
+				{next: respondWith(404)},
+				{next: respondWith(500)},
+			},
+		},
+		{
+			name: "timeouts and cancellations aren't counted",
+			steps: []step{
+				{next: timeout, err: context.DeadlineExceeded},
+				{next: timeout, err: context.DeadlineExceeded},
+				{next: cancelled, err: context.Canceled},
+				{next: timeout, err: context.DeadlineExceeded},
+				{next: respondWith(200)},
+			},
+		},
+		{
+			name: "probe after cooldown closes the circuit",
+			steps: []step{
+				{next: respondWith(500)},
+				{next: respondWith(500)},
+				{next: respondWith(500), open: true},
+				{next: respondWith(200), wait: true},
+				{next: respondWith(500)},
+			},
+		},
+		{
+			name: "failed probe opens it again",
+			steps: []step{
+				{next: respondWith(500)},
+				{next: respondWith(500)},
+				{next: respondWith(500), open: true},
+				{next: respondWith(500), wait: true, open: true},
+				{next: respondWith(200), open: true, err: ErrCircuitOpen},
+			},
+		},
+		{
+			name: "probe without a response lets another through",
+			steps: []step{
+				{next: respondWith(500)},
+				{next: respondWith(500)},
+				{next: respondWith(500), open: true},
+				{next: cancelled, wait: true, err: context.Canceled},
+				{next: respondWith(200)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cb, err := NewCircuitBreaker(3, 20*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, s := range test.steps {
+			if s.wait {
+				time.Sleep(30 * time.Millisecond)
+			}
+
+			req, _ := http.NewRequest("GET", "https://www.inaturalist.org/observations.json", nil)
+			resp, err := cb.Middleware()(s.next).RoundTrip(req)
+			if err != s.err {
+				t.Errorf("%s, step %d: expected error %v, got %v", test.name, i, s.err, err)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+			if open := cb.Open("www.inaturalist.org"); open != s.open {
+				t.Errorf("%s, step %d: expected open %v", test.name, i, s.open)
+			}
+		}
+
+		if cb.Open("api.inaturalist.org") {
+			t.Errorf("%s: expected other hosts to be unaffected", test.name)
+		}
+	}
+}
+
+func TestCircuitBreakerProbesOnce(t *testing.T) {
+	cb, _ := NewCircuitBreaker(1, 0)
+
+	req, _ := http.NewRequest("GET", "https://www.inaturalist.org/observations.json", nil)
+	if _, err := cb.Middleware()(respondWith(500)).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	probing := make(chan struct{})
+	finish := make(chan struct{})
+	slow := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(probing)
+		<-finish
+		return respondWith(200)(req)
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := cb.Middleware()(slow).RoundTrip(req)
+		done <- err
+	}()
+
+	<-probing
+	if _, err := cb.Middleware()(respondWith(200)).RoundTrip(req); err != ErrCircuitOpen {
+		t.Errorf("expected only one probe at a time, got %v", err)
+	}
+	close(finish)
+	if err := <-done; err != nil {
+		t.Errorf("expected the probe to succeed, got %v", err)
+	}
+	if _, err := cb.Middleware()(respondWith(200)).RoundTrip(req); err != nil {
+		t.Errorf("expected the circuit to be closed, got %v", err)
+	}
+}
+
+func TestLoggingRedacts(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.New(&buffer, "", 0)
+
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, _ := respondWith(200)(req)
+		resp.Header.Set("Set-Cookie", "session=secret-session")
+		resp.Header.Set("X-Page", "1")
+		return resp, nil
+	})
+
+	req, _ := http.NewRequest("GET", "https://www.inaturalist.org/oauth/token?code=secret-code&grant_type=authorization_code", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Api-Key", "secret-key")
+	req.Header.Set("Accept", "application/json")
+
+	if _, err := Logging(logger, "x-api-key")(next).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	logged := buffer.String()
+	for _, secret := range []string{"secret-token", "secret-key", "secret-code", "secret-session"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("expected %s to be redacted from:\n%s", secret, logged)
+		}
+	}
+	for _, kept := range []string{"Authorization: REDACTED", "X-Api-Key: REDACTED", "Accept: application/json", "grant_type=authorization_code", "X-Page: 1", " 200 in "} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("expected %q in:\n%s", kept, logged)
+		}
+	}
+	if req.Header.Get("Authorization") != "Bearer secret-token" {
+		t.Errorf("expected the request itself to be unchanged")
+	}
+}
+
+func gzipped(s string) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.Bytes()
+}
+
+func TestGzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         http.Header
+		encoding       string
+		body           []byte
+		expected       string
+		acceptEncoding string
+	}{
+		{"decompresses", http.Header{}, "gzip", gzipped("[1,2,3]"), "[1,2,3]", "gzip"},
+		{"uncompressed response", http.Header{}, "", []byte("[1,2,3]"), "[1,2,3]", "gzip"},
+		{"caller's encoding left alone", http.Header{"Accept-Encoding": {"br"}}, "gzip", gzipped("x"), string(gzipped("x")), "br"},
+		{"ranges left alone", http.Header{"Range": {"bytes=0-1"}}, "", []byte("[1"), "[1", ""},
+	}
+
+	for _, test := range tests {
+		var sent http.Header
+		next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req.Header
+			header := http.Header{}
+			if test.encoding != "" {
+				header.Set("Content-Encoding", test.encoding)
+			}
+			return &http.Response{
+				StatusCode:    200,
+				Header:        header,
+				Body:          ioutil.NopCloser(bytes.NewReader(test.body)),
+				ContentLength: int64(len(test.body)),
+			}, nil
+		})
+
+		req, _ := http.NewRequest("GET", "https://www.inaturalist.org/observations.json", nil)
+		req.Header = test.header
+
+		resp, err := Gzip()(next).RoundTrip(req)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if string(body) != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, body)
+		}
+		if sent.Get("Accept-Encoding") != test.acceptEncoding {
+			t.Errorf("%s: expected Accept-Encoding %q, got %q", test.name, test.acceptEncoding, sent.Get("Accept-Encoding"))
+		}
+		if test.encoding == "gzip" && test.acceptEncoding == "gzip" && (resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 || !resp.Uncompressed) {
+			t.Errorf("%s: expected the response to be marked uncompressed", test.name)
+		}
+		if req.Header.Get("Accept-Encoding") != test.header.Get("Accept-Encoding") {
+			t.Errorf("%s: expected the request itself to be unchanged", test.name)
+		}
+	}
+
+	corrupt := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Encoding": {"gzip"}},
+			Body:       ioutil.NopCloser(strings.NewReader("not gzip")),
+		}, nil
+	})
+	req, _ := http.NewRequest("GET", "https://www.inaturalist.org/observations.json", nil)
+	resp, err := Gzip()(corrupt).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(resp.Body); err == nil {
+		t.Errorf("expected an error reading a corrupt body")
+	}
+}
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		existing string
+	}{
+		{""},
+		{"caller-id"},
+	}
+
+	for _, test := range tests {
+		var sent string
+		next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req.Header.Get(RequestIdHeader)
+			return respondWith(200)(req)
+		})
+
+		req, _ := http.NewRequest("GET", "https://www.inaturalist.org/observations.json", nil)
+		if test.existing != "" {
+			req.Header.Set(RequestIdHeader, test.existing)
+		}
+
+		if _, err := RequestId()(next).RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+
+		if test.existing != "" && sent != test.existing {
+			t.Errorf("expected %q to be kept, got %q", test.existing, sent)
+		}
+		if test.existing == "" && len(sent) != 32 {
+			t.Errorf("expected a generated id, got %q", sent)
+		}
+		if req.Header.Get(RequestIdHeader) != test.existing {
+			t.Errorf("expected the request itself to be unchanged")
+		}
+	}
+
+	first, second := newRequestId(), newRequestId()
+	if first == second {
+		t.Errorf("expected unique ids, got %s twice", first)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	order := make([]string, 0)
+	named := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the access token beneath the client")
+		}
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	c.Use(named("outer"), named("inner"))
+	if _, err := c.GetObservation(1); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("expected outer then inner, got %v", order)
+	}
+}
@@ -0,0 +1,283 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ObservationFieldDatatype string
+
+const (
+	TextField     ObservationFieldDatatype = "text"
+	NumericField  ObservationFieldDatatype = "numeric"
+	DateField     ObservationFieldDatatype = "date"
+	TimeField     ObservationFieldDatatype = "time"
+	DateTimeField ObservationFieldDatatype = "datetime"
+	TaxonField    ObservationFieldDatatype = "taxon"
+	DnaField      ObservationFieldDatatype = "dna"
+)
+
+type ObservationFieldDefinition struct {
+	Id            int64                    `json:"id"`
+	Name          string                   `json:"name"`
+	Datatype      ObservationFieldDatatype `json:"datatype"`
+	Description   string                   `json:"description"`
+	AllowedValues string                   `json:"allowed_values"`
+	UserId        int64                    `json:"user_id"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+}
+
+func (f *ObservationFieldDefinition) AllowedValuesList() []string {
+	if f.AllowedValues == "" {
+		return nil
+	}
+	return strings.Split(f.AllowedValues, "|")
+}
+
+type ObservationFieldValue struct {
+	Id                 int64                       `json:"id"`
+	Uuid               string                      `json:"uuid"`
+	ObservationId      int64                       `json:"observation_id"`
+	ObservationFieldId int64                       `json:"observation_field_id"`
+	Value              string                      `json:"value"`
+	UserId             int64                       `json:"user_id"`
+	CreatedAt          time.Time                   `json:"created_at"`
+	UpdatedAt          time.Time                   `json:"updated_at"`
+	ObservationField   *ObservationFieldDefinition `json:"observation_field"`
+}
+
+func (v *ObservationFieldValue) Datatype() ObservationFieldDatatype {
+	if v.ObservationField == nil {
+		return TextField
+	}
+	return v.ObservationField.Datatype
+}
+
+func (v *ObservationFieldValue) Text() string {
+	return v.Value
+}
+
+func (v *ObservationFieldValue) Numeric() (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(v.Value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid numeric value: '%s'", v.Value)
+	}
+	return n, nil
+}
+
+func (v *ObservationFieldValue) Date() (time.Time, error) {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(v.Value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid date value: '%s'", v.Value)
+	}
+	return t, nil
+}
+
+var acceptableFieldTimeFormats = []string{
+	"15:04",
+	"15:04:05",
+	"3:04 PM",
+	"3:04PM",
+}
+
+func (v *ObservationFieldValue) Time() (time.Time, error) {
+	str := strings.TrimSpace(v.Value)
+	for _, l := range acceptableFieldTimeFormats {
+		t, err := time.Parse(l, str)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid time value: '%s'", v.Value)
+}
+
+func (v *ObservationFieldValue) DateTime() (time.Time, error) {
+	return TryParseObservedOn(v.Value)
+}
+
+func (v *ObservationFieldValue) TaxonId() (int32, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(v.Value), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid taxon value: '%s'", v.Value)
+	}
+	return int32(id), nil
+}
+
+func (v *ObservationFieldValue) Dna() (string, error) {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(v.Value) {
+		switch {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			continue
+		case strings.ContainsRune("ACGTURYKMSWBDHVN-", r):
+			sb.WriteRune(r)
+		default:
+			return "", fmt.Errorf("Invalid dna value: '%s'", v.Value)
+		}
+	}
+	return sb.String(), nil
+}
+
+// Typed returns the value converted according to the field's datatype: a
+// float64, time.Time, int32 (taxon id) or string.
+func (v *ObservationFieldValue) Typed() (interface{}, error) {
+	switch v.Datatype() {
+	case NumericField:
+		return v.Numeric()
+	case DateField:
+		return v.Date()
+	case TimeField:
+		return v.Time()
+	case DateTimeField:
+		return v.DateTime()
+	case TaxonField:
+		return v.TaxonId()
+	case DnaField:
+		return v.Dna()
+	}
+	return v.Text(), nil
+}
+
+type ObservationFieldsPage struct {
+	Paging            *PageHeaders
+	ObservationFields []*ObservationFieldDefinition
+}
+
+type GetObservationFieldsOpt struct {
+	Page  *int
+	Query *string
+}
+
+func (c *Client) GetObservationFields(opt *GetObservationFieldsOpt) (*ObservationFieldsPage, error) {
+	var result []*ObservationFieldDefinition
+
+	u := c.buildUrl("/observation_fields.json")
+	if opt != nil {
+		v := url.Values{}
+		if opt.Page != nil {
+			v.Set("page", strconv.Itoa(*opt.Page))
+		}
+		if opt.Query != nil {
+			v.Set("q", *opt.Query)
+		}
+		if params := v.Encode(); params != "" {
+			u += "?" + params
+		}
+	}
+	p, err := c.get(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting observation fields: %v", err)
+	}
+
+	return &ObservationFieldsPage{
+		ObservationFields: result,
+		Paging:            p,
+	}, nil
+}
+
+func (c *Client) GetObservationField(id int64) (*ObservationFieldDefinition, error) {
+	var result ObservationFieldDefinition
+
+	u := c.buildUrl("/observation_fields/%d.json", id)
+	_, err := c.get(u, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type SetObservationFieldValueOpt struct {
+	ObservationId      int64  `json:"observation_id"`
+	ObservationFieldId int64  `json:"observation_field_id"`
+	Value              string `json:"value"`
+}
+
+type setObservationFieldValueBody struct {
+	ObservationFieldValue *SetObservationFieldValueOpt `json:"observation_field_value"`
+}
+
+// SetObservationFieldValue creates the value or replaces the existing value
+// of the same field on the observation.
+func (c *Client) SetObservationFieldValue(opt *SetObservationFieldValueOpt) (*ObservationFieldValue, error) {
+	u := c.buildUrl("/observation_field_values.json")
+
+	bodyJson, err := json.Marshal(&setObservationFieldValueBody{ObservationFieldValue: opt})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", u, bytes.NewReader(bodyJson))
+	if err != nil {
+		return nil, err
+	}
+	var result ObservationFieldValue
+	err = c.execute(req, &result, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateObservations()
+
+	return &result, nil
+}
+
+func (c *Client) DeleteObservationFieldValue(id int64) error {
+	u := c.buildUrl("/observation_field_values/%d.json", id)
+
+	empty := make([]byte, 0)
+
+	req, err := http.NewRequest("DELETE", u, bytes.NewReader(empty))
+	if err != nil {
+		return err
+	}
+	err = c.execute(req, nil, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+type ObservationFieldValueAttributes struct {
+	ObservationFieldId int64  `json:"observation_field_id"`
+	Value              string `json:"value"`
+}
+
+// FormatObservationFieldValue renders a Go value in the form the server
+// expects for the given datatype.
+func FormatObservationFieldValue(datatype ObservationFieldDatatype, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		switch datatype {
+		case DateField:
+			return v.Format("2006-01-02"), nil
+		case TimeField:
+			return v.Format("15:04"), nil
+		default:
+			return v.Format(time.RFC3339), nil
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+	return "", fmt.Errorf("Unsupported observation field value: %v", value)
+}
@@ -0,0 +1,156 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestObservationFieldValueTyped(t *testing.T) {
+	tests := []struct {
+		datatype ObservationFieldDatatype
+		value    string
+		expected interface{}
+		fails    bool
+	}{
+		{TextField, " anything ", " anything ", false},
+		{"", "untyped", "untyped", false},
+		{NumericField, " 12.5 ", 12.5, false},
+		{NumericField, "-3", -3.0, false},
+		{NumericField, "twelve", nil, true},
+		{DateField, "2017-03-04", time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC), false},
+		{DateField, "03/04/2017", nil, true},
+		{TimeField, "14:30", time.Date(0, 1, 1, 14, 30, 0, 0, time.UTC), false},
+		{TimeField, "14:30:15", time.Date(0, 1, 1, 14, 30, 15, 0, time.UTC), false},
+		{TimeField, "2:30 PM", time.Date(0, 1, 1, 14, 30, 0, 0, time.UTC), false},
+		{TimeField, "2:30PM", time.Date(0, 1, 1, 14, 30, 0, 0, time.UTC), false},
+		{TimeField, "half past two", nil, true},
+		{DateTimeField, "2017-03-04T14:30:00-07:00", time.Date(2017, 3, 4, 14, 30, 0, 0, time.FixedZone("", -7*3600)), false},
+		{DateTimeField, "whenever", nil, true},
+		{TaxonField, "48484", int32(48484), false},
+		{TaxonField, "Danaus plexippus", nil, true},
+		{TaxonField, "99999999999", nil, true},
+		{DnaField, "acgt nnu\nRYK-", "ACGTNNURYK-", false},
+		{DnaField, "ACGTX", nil, true},
+	}
+
+	for _, test := range tests {
+		v := &ObservationFieldValue{
+			Value:            test.value,
+			ObservationField: &ObservationFieldDefinition{Datatype: test.datatype},
+		}
+		if test.datatype == "" {
+			v.ObservationField = nil
+		}
+
+		typed, err := v.Typed()
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s %q: expected an error, got %v", test.datatype, test.value, typed)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", test.datatype, test.value, err)
+			continue
+		}
+
+		if expected, ok := test.expected.(time.Time); ok {
+			actual, ok := typed.(time.Time)
+			if !ok || !actual.Equal(expected) {
+				t.Errorf("%s %q: expected %v, got %v", test.datatype, test.value, expected, typed)
+			}
+			continue
+		}
+		if typed != test.expected {
+			t.Errorf("%s %q: expected %v (%T), got %v (%T)", test.datatype, test.value, test.expected, test.expected, typed, typed)
+		}
+	}
+}
+
+func TestFormatObservationFieldValue(t *testing.T) {
+	at := time.Date(2017, 3, 4, 14, 30, 15, 0, time.FixedZone("", -7*3600))
+
+	tests := []struct {
+		datatype ObservationFieldDatatype
+		value    interface{}
+		expected string
+		fails    bool
+	}{
+		{TextField, "text", "text", false},
+		{DateField, at, "2017-03-04", false},
+		{TimeField, at, "14:30", false},
+		{DateTimeField, at, "2017-03-04T14:30:15-07:00", false},
+		{NumericField, 12.5, "12.5", false},
+		{NumericField, float32(0.1), "0.1", false},
+		{NumericField, 3, "3", false},
+		{TaxonField, int32(48484), "48484", false},
+		{TaxonField, int64(48484), "48484", false},
+		{NumericField, true, "", true},
+		{NumericField, nil, "", true},
+	}
+
+	for _, test := range tests {
+		actual, err := FormatObservationFieldValue(test.datatype, test.value)
+		if (err != nil) != test.fails {
+			t.Errorf("%s %v: unexpected error %v", test.datatype, test.value, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s %v: expected %q, got %q", test.datatype, test.value, test.expected, actual)
+		}
+	}
+}
+
+func TestObservationFieldValueRoundTrips(t *testing.T) {
+	tests := []struct {
+		datatype ObservationFieldDatatype
+		value    interface{}
+	}{
+		{NumericField, 12.5},
+		{DateField, time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{TimeField, time.Date(0, 1, 1, 14, 30, 0, 0, time.UTC)},
+		{DateTimeField, time.Date(2017, 3, 4, 14, 30, 15, 0, time.UTC)},
+		{TaxonField, int32(48484)},
+		{DnaField, "ACGT"},
+		{TextField, "text"},
+	}
+
+	for _, test := range tests {
+		formatted, err := FormatObservationFieldValue(test.datatype, test.value)
+		if err != nil {
+			t.Errorf("%s: %v", test.datatype, err)
+			continue
+		}
+		v := &ObservationFieldValue{
+			Value:            formatted,
+			ObservationField: &ObservationFieldDefinition{Datatype: test.datatype},
+		}
+		typed, err := v.Typed()
+		if err != nil {
+			t.Errorf("%s: %v", test.datatype, err)
+			continue
+		}
+		if fmt.Sprint(typed) != fmt.Sprint(test.value) {
+			t.Errorf("%s: expected %v, got %v", test.datatype, test.value, typed)
+		}
+	}
+}
+
+func TestAllowedValuesList(t *testing.T) {
+	tests := []struct {
+		allowed  string
+		expected []string
+	}{
+		{"", nil},
+		{"yes", []string{"yes"}},
+		{"yes|no|maybe", []string{"yes", "no", "maybe"}},
+	}
+
+	for _, test := range tests {
+		f := &ObservationFieldDefinition{AllowedValues: test.allowed}
+		if actual := f.AllowedValuesList(); fmt.Sprint(actual) != fmt.Sprint(test.expected) || (actual == nil) != (test.expected == nil) {
+			t.Errorf("%q: expected %v, got %v", test.allowed, test.expected, actual)
+		}
+	}
+}
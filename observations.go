@@ -8,39 +8,60 @@ import (
 	"net/url"
 	"strconv"
 	"time"
-)
-
-type Location struct {
-	Longitude float64
-	Latitude  float64
-}
 
-type Rectangle struct {
-	Southwest Location
-	Northeast Location
-}
+	"golang.org/x/net/context"
+)
 
 type SimpleObservation struct {
-	Id                       int64     `json:"id"`
-	UserLogin                string    `json:"user_login"`
-	PlaceGuess               string    `json:"place_guess"`
-	SpeciesGuess             string    `json:"species_guess"`
-	Latitude                 float64   `json:"latitude,string"`
-	Longitude                float64   `json:"longitude,string"`
-	CreatedAt                time.Time `json:"created_at_utc"`
-	ObservedOn               string    `json:"observed_on"`
-	ObservedOnString         string    `json:"observed_on_string"`
-	UpdatedAt                time.Time `json:"updated_at_utc"`
-	TaxonId                  int32     `json:"taxon_id"`
-	UserId                   int64     `json:"user_id"`
-	SiteId                   int64     `json:"site_id"`
-	TimeZone                 string    `json:"time_zone"`
-	Description              string    `json:"description"`
-	Uri                      string    `json:"uri"`
-	Uuid                     string    `json:"uuid"`
-	TimeObservedAtUtc        time.Time `json:"time_observed_at_utc"`
-	PositionalAccuracy       int32     `json:"positional_accuracy"`
-	PublicPositionalAccuracy int32     `json:"public_positional_accuracy"`
+	Id                             int64                    `json:"id"`
+	UserLogin                      string                   `json:"user_login"`
+	PlaceGuess                     string                   `json:"place_guess"`
+	SpeciesGuess                   string                   `json:"species_guess"`
+	Latitude                       float64                  `json:"latitude,string"`
+	Longitude                      float64                  `json:"longitude,string"`
+	PrivateLatitude                float64                  `json:"private_latitude,string"`
+	PrivateLongitude               float64                  `json:"private_longitude,string"`
+	Obscured                       bool                     `json:"obscured"`
+	CreatedAt                      time.Time                `json:"created_at_utc"`
+	ObservedOn                     string                   `json:"observed_on"`
+	ObservedOnString               string                   `json:"observed_on_string"`
+	UpdatedAt                      time.Time                `json:"updated_at_utc"`
+	TaxonId                        int32                    `json:"taxon_id"`
+	UserId                         int64                    `json:"user_id"`
+	SiteId                         int64                    `json:"site_id"`
+	TimeZone                       string                   `json:"time_zone"`
+	ZicTimeZone                    string                   `json:"zic_time_zone"`
+	Description                    string                   `json:"description"`
+	Uri                            string                   `json:"uri"`
+	Uuid                           string                   `json:"uuid"`
+	TimeObservedAtUtc              time.Time                `json:"time_observed_at_utc"`
+	PositionalAccuracy             int32                    `json:"positional_accuracy"`
+	PublicPositionalAccuracy       int32                    `json:"public_positional_accuracy"`
+	Geoprivacy                     GeoPrivacy               `json:"geoprivacy"`
+	TaxonGeoprivacy                GeoPrivacy               `json:"taxon_geoprivacy"`
+	Captive                        bool                     `json:"captive"`
+	QualityGrade                   QualityGrade             `json:"quality_grade"`
+	License                        string                   `json:"license"`
+	OAuthApplicationId             int64                    `json:"oauth_application_id"`
+	CommunityTaxonId               int32                    `json:"community_taxon_id"`
+	IconicTaxonId                  int32                    `json:"iconic_taxon_id"`
+	IconicTaxonName                string                   `json:"iconic_taxon_name"`
+	OutOfRange                     bool                     `json:"out_of_range"`
+	IdentificationsCount           int32                    `json:"identifications_count"`
+	CommentsCount                  int32                    `json:"comments_count"`
+	NumIdentificationAgreements    int32                    `json:"num_identification_agreements"`
+	NumIdentificationDisagreements int32                    `json:"num_identification_disagreements"`
+	FavesCount                     int32                    `json:"faves_count"`
+	PhotosCount                    int32                    `json:"observation_photos_count"`
+	SoundsCount                    int32                    `json:"observation_sounds_count"`
+	Taxon                          *SimpleTaxon             `json:"taxon"`
+	User                           *SimpleUser              `json:"user"`
+	Identifications                []*Identification        `json:"identifications"`
+	Faves                          []*Fave                  `json:"faves"`
+	ObservationFieldValues         []*ObservationFieldValue `json:"observation_field_values"`
+	Annotations                    []*Annotation            `json:"annotations"`
+	QualityMetrics                 []*QualityMetric         `json:"quality_metrics"`
+	Votes                          []*Vote                  `json:"votes"`
 }
 
 type ObservationsPage struct {
@@ -73,14 +94,61 @@ type Comment struct {
 	User      SimpleUser
 }
 
+type Fave struct {
+	Id        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	User      SimpleUser `json:"user"`
+}
+
+type PhotoDimensions struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
 type SimplePhoto struct {
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Id        int64     `json:"id"`
-	LargeUrl  string    `json:"large_url"`
-	MediumUrl string    `json:"medium_url"`
-	SmallUrl  string    `json:"small_url"`
-	SquareUrl string    `json:"square_url"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+	Id                 int64            `json:"id"`
+	Url                string           `json:"url"`
+	LargeUrl           string           `json:"large_url"`
+	MediumUrl          string           `json:"medium_url"`
+	SmallUrl           string           `json:"small_url"`
+	SquareUrl          string           `json:"square_url"`
+	ThumbUrl           string           `json:"thumb_url"`
+	OriginalUrl        string           `json:"original_url"`
+	License            License          `json:"license_code"`
+	Attribution        string           `json:"attribution"`
+	NativePhotoId      string           `json:"native_photo_id"`
+	OriginalDimensions *PhotoDimensions `json:"original_dimensions"`
+}
+
+// UnmarshalJSON also takes the license from the number the Rails API
+// gives, and native photo ids that are numbers.
+func (p *SimplePhoto) UnmarshalJSON(b []byte) error {
+	type photo SimplePhoto
+	aux := struct {
+		*photo
+		RailsLicense  *License    `json:"license"`
+		NativePhotoId interface{} `json:"native_photo_id"`
+	}{
+		photo: (*photo)(p),
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	if p.License == "" && aux.RailsLicense != nil {
+		p.License = *aux.RailsLicense
+	}
+
+	switch id := aux.NativePhotoId.(type) {
+	case string:
+		p.NativePhotoId = id
+	case float64:
+		p.NativePhotoId = strconv.FormatFloat(id, 'f', -1, 64)
+	}
+
+	return nil
 }
 
 type ObservationPhoto struct {
@@ -93,15 +161,10 @@ type ObservationPhoto struct {
 }
 
 type FullObservation struct {
-	Id               int64                 `json:"id"`
-	CreatedAt        time.Time             `json:"created_at"`
-	UpdatedAt        time.Time             `json:"updated_at"`
-	Longitude        string                `json:"longitude"`
-	Latitude         string                `json:"latitude"`
-	ObservedOnString string                `json:"observed_on_string"`
-	Photos           []*ObservationPhoto   `json:"observation_photos"`
-	Comments         []*Comment            `json:"comments"`
-	Projects         []*ProjectObservation `json:"project_observations"`
+	SimpleObservation
+	Photos   []*ObservationPhoto   `json:"observation_photos"`
+	Comments []*Comment            `json:"comments"`
+	Projects []*ProjectObservation `json:"project_observations"`
 }
 
 type GetObservationsOpt struct {
@@ -109,85 +172,251 @@ type GetObservationsOpt struct {
 	Page           *int
 	Rectangle      *Rectangle
 	On             *time.Time
+	ObservedOn     *FuzzyDate
 	UpdatedSince   *time.Time
 	OrderBy        *string
 	OrderAscending *bool
 	HasGeo         *bool
+	From           *time.Time
+	To             *time.Time
+	ProjectId      *int64
+	ProjectSlug    string
+	PlaceId        *int64
+	TaxonId        *int32
+	UserId         *int64
+	UserLogin      string
+	TermId         *int64
+	TermValueId    *int64
+	QualityGrade   *QualityGrade
+	// PhotoLicense only includes observations with a photo under one of
+	// the licenses.
+	PhotoLicense []License
 }
 
+// TryParseObservedOn parses observed_on_string in the observation's time
+// zone, see ObservedTime for one that also keeps the precision.
 func (o *SimpleObservation) TryParseObservedOn() (time.Time, error) {
-	return TryParseObservedOn(o.ObservedOnString)
+	loc, err := o.TimeZoneLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	parsed, err := ParseFuzzyDateIn(o.ObservedOnString, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.Time, nil
 }
 
-func (c *Client) GetObservations(opt *GetObservationsOpt) (*ObservationsPage, error) {
-	var result []*SimpleObservation
+func (o *FullObservation) Simple() *SimpleObservation {
+	return &o.SimpleObservation
+}
 
-	u := c.buildUrl("/observations.json")
-	if opt != nil {
-		v := url.Values{}
-		if opt.Page != nil {
-			v.Set("page", strconv.Itoa(*opt.Page))
-		}
-		if opt.PerPage != nil {
-			v.Set("per_page", strconv.Itoa(*opt.PerPage))
-		}
-		if opt.Rectangle != nil {
-			v.Set("swlng", fmt.Sprintf("%v", opt.Rectangle.Southwest.Longitude))
-			v.Set("swlat", fmt.Sprintf("%v", opt.Rectangle.Southwest.Latitude))
-			v.Set("nelng", fmt.Sprintf("%v", opt.Rectangle.Northeast.Longitude))
-			v.Set("nelat", fmt.Sprintf("%v", opt.Rectangle.Northeast.Latitude))
-		}
-		if opt.OrderBy != nil {
-			v.Set("order_by", *opt.OrderBy)
-			if opt.OrderAscending == nil {
-				v.Set("order", "desc")
-			}
-		}
-		if opt.OrderAscending != nil {
-			if *opt.OrderAscending {
-				v.Set("order", "asc")
-			} else {
-				v.Set("order", "desc")
-			}
+func (opt *GetObservationsOpt) values() url.Values {
+	v := url.Values{}
+	if opt.Page != nil {
+		v.Set("page", strconv.Itoa(*opt.Page))
+	}
+	if opt.PerPage != nil {
+		v.Set("per_page", strconv.Itoa(*opt.PerPage))
+	}
+	if opt.Rectangle != nil {
+		v.Set("swlng", fmt.Sprintf("%v", opt.Rectangle.Southwest.Longitude))
+		v.Set("swlat", fmt.Sprintf("%v", opt.Rectangle.Southwest.Latitude))
+		v.Set("nelng", fmt.Sprintf("%v", opt.Rectangle.Northeast.Longitude))
+		v.Set("nelat", fmt.Sprintf("%v", opt.Rectangle.Northeast.Latitude))
+	}
+	if opt.OrderBy != nil {
+		v.Set("order_by", *opt.OrderBy)
+		if opt.OrderAscending == nil {
+			v.Set("order", "desc")
 		}
-		if opt.UpdatedSince != nil {
-			v.Set("updated_since", opt.UpdatedSince.Format(time.RFC3339))
+	}
+	if opt.OrderAscending != nil {
+		if *opt.OrderAscending {
+			v.Set("order", "asc")
+		} else {
+			v.Set("order", "desc")
 		}
-		if opt.HasGeo != nil {
-			v.Set("has[]", "geo")
+	}
+	if opt.UpdatedSince != nil {
+		v.Set("updated_since", opt.UpdatedSince.Format(time.RFC3339))
+	}
+	if opt.HasGeo != nil {
+		v.Set("has[]", "geo")
+	}
+	if opt.On != nil {
+		v.Set("on", opt.On.Format("2006-01-02"))
+	}
+	if opt.ObservedOn != nil {
+		switch opt.ObservedOn.Precision {
+		case YearPrecision:
+			v.Set("year", strconv.Itoa(opt.ObservedOn.Time.Year()))
+		case MonthPrecision:
+			v.Set("year", strconv.Itoa(opt.ObservedOn.Time.Year()))
+			v.Set("month", strconv.Itoa(int(opt.ObservedOn.Time.Month())))
+		case UnknownPrecision:
+		default:
+			v.Set("on", opt.ObservedOn.Date())
 		}
-		if opt.On != nil {
-			v.Set("on", opt.On.Format("2006-01-02"))
+	}
+	if opt.From != nil {
+		v.Set("d1", opt.From.Format("2006-01-02"))
+	}
+	if opt.To != nil {
+		v.Set("d2", opt.To.Format("2006-01-02"))
+	}
+	if opt.ProjectId != nil {
+		v.Set("project_id", strconv.FormatInt(*opt.ProjectId, 10))
+	} else if opt.ProjectSlug != "" {
+		v.Set("project_id", opt.ProjectSlug)
+	}
+	if opt.PlaceId != nil {
+		v.Set("place_id", strconv.FormatInt(*opt.PlaceId, 10))
+	}
+	if opt.TaxonId != nil {
+		v.Set("taxon_id", strconv.FormatInt(int64(*opt.TaxonId), 10))
+	}
+	if opt.UserId != nil {
+		v.Set("user_id", strconv.FormatInt(*opt.UserId, 10))
+	} else if opt.UserLogin != "" {
+		v.Set("user_id", opt.UserLogin)
+	}
+	if opt.TermId != nil {
+		v.Set("term_id", strconv.FormatInt(*opt.TermId, 10))
+	}
+	if opt.TermValueId != nil {
+		v.Set("term_value_id", strconv.FormatInt(*opt.TermValueId, 10))
+	}
+	if opt.QualityGrade != nil {
+		v.Set("quality_grade", string(*opt.QualityGrade))
+	}
+	if len(opt.PhotoLicense) == 1 {
+		v.Set("photo_license", opt.PhotoLicense[0].searchValue())
+	} else {
+		for _, l := range opt.PhotoLicense {
+			v.Add("photo_license[]", l.searchValue())
 		}
-		if params := v.Encode(); params != "" {
+	}
+	return v
+}
+
+// observationQueries validates opt and returns the queries to make for it.
+// The server doesn't handle a southwest corner east of the northeast one, so
+// a rectangle crossing the antimeridian is queried as each of its halves.
+func observationQueries(opt *GetObservationsOpt) ([]*GetObservationsOpt, error) {
+	if opt == nil || opt.Rectangle == nil {
+		return []*GetObservationsOpt{opt}, nil
+	}
+	if err := opt.Rectangle.Validate(); err != nil {
+		return nil, err
+	}
+	halves := opt.Rectangle.Split()
+	queries := make([]*GetObservationsOpt, len(halves))
+	for i := range halves {
+		half := *opt
+		half.Rectangle = &halves[i]
+		queries[i] = &half
+	}
+	return queries, nil
+}
+
+func (c *Client) observationsUrl(opt *GetObservationsOpt) string {
+	u := c.buildUrl("/observations.json")
+	if opt != nil {
+		if params := opt.values().Encode(); params != "" {
 			u += "?" + params
 		}
 	}
-	p, err := c.get(u, &result)
-	if err != nil {
-		return nil, fmt.Errorf("Error getting observations: %v", err)
+	return u
+}
+
+// mergePaging sums the totals of the pages of each half of a query.
+func mergePaging(merged *PageHeaders, p *PageHeaders) *PageHeaders {
+	if p == nil {
+		return merged
 	}
+	if merged == nil {
+		paging := *p
+		return &paging
+	}
+	merged.TotalEntries += p.TotalEntries
+	return merged
+}
 
-	return &ObservationsPage{
-		Observations: result,
-		Paging:       p,
-	}, nil
+// GetObservations gets a page of observations. A rectangle crossing the
+// antimeridian is queried as its two halves, page n being page n of the
+// west half followed by page n of the east one, so it may hold up to twice
+// PerPage observations. TotalEntries is the sum for both halves, so paging
+// up to TotalEntries/PerPage returns every observation once, though the
+// last pages may be empty. FetchObservationPages pages through each half
+// in turn instead.
+func (c *Client) GetObservations(opt *GetObservationsOpt) (*ObservationsPage, error) {
+	return c.GetObservationsContext(context.Background(), opt)
 }
 
 type AddObservationOpt struct {
-	SpeciesGuess       string    `json:"species_guess"`
-	ObservedOnString   time.Time `json:"observed_on_string,omit_empty"`
-	Description        string    `json:"description"`
-	Latitude           float64   `json:"latitude"`
-	Longitude          float64   `json:"longitude"`
-	PositionalAccuracy int32     `json:"positional_accuracy"`
-	Tags               string    `json:"tag_list"`
-	GeoPrivacy         string    `json:"geoprivacy"`
+	SpeciesGuess           string                             `json:"species_guess"`
+	ObservedOnString       time.Time                          `json:"observed_on_string,omit_empty"`
+	Description            string                             `json:"description"`
+	Latitude               float64                            `json:"latitude"`
+	Longitude              float64                            `json:"longitude"`
+	PositionalAccuracy     int32                              `json:"positional_accuracy"`
+	Tags                   string                             `json:"tag_list"`
+	GeoPrivacy             GeoPrivacy                         `json:"geoprivacy"`
+	ObservationFieldValues []*ObservationFieldValueAttributes `json:"observation_field_values_attributes,omitempty"`
+	// ObservedOn takes precedence over ObservedOnString and allows
+	// partial dates.
+	ObservedOn *FuzzyDate `json:"-"`
+	// TimeZone overrides the zone derived from the observed time's location.
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// MarshalJSON sends ObservedOnString as wall time in its location along
+// with that location's zone, so the server doesn't reinterpret it in the
+// user's default zone.
+func (o *AddObservationOpt) MarshalJSON() ([]byte, error) {
+	type plain AddObservationOpt
+	body := struct {
+		*plain
+		ObservedOnString   string   `json:"observed_on_string,omitempty"`
+		TimeZone           string   `json:"time_zone,omitempty"`
+		Latitude           *float64 `json:"latitude,omitempty"`
+		Longitude          *float64 `json:"longitude,omitempty"`
+		PositionalAccuracy *int32   `json:"positional_accuracy,omitempty"`
+	}{
+		plain:    (*plain)(o),
+		TimeZone: o.TimeZone,
+	}
+	// Zero for both is no location, as for photos without GPS, rather
+	// than a point in the Gulf of Guinea. Zero accuracy is unknown.
+	if o.Latitude != 0 || o.Longitude != 0 {
+		body.Latitude = &o.Latitude
+		body.Longitude = &o.Longitude
+		if o.PositionalAccuracy > 0 {
+			body.PositionalAccuracy = &o.PositionalAccuracy
+		}
+	}
+	observedOn := NewFuzzyDate(o.ObservedOnString, SecondPrecision)
+	if o.ObservedOn != nil {
+		observedOn = *o.ObservedOn
+	}
+	if observedOn.IsSet() && !observedOn.Time.IsZero() {
+		value, zone := FormatObservedOnString(observedOn.Time, observedOn.Precision)
+		body.ObservedOnString = value
+		if body.TimeZone == "" {
+			body.TimeZone = zone
+		}
+	}
+	return json.Marshal(&body)
 }
 
 func (c *Client) AddObservation(opt *AddObservationOpt) (*SimpleObservation, error) {
 	u := c.buildUrl("/observations.json")
 
+	if err := opt.GeoPrivacy.Validate(); err != nil {
+		return nil, err
+	}
+
 	bodyJson, err := json.Marshal(opt)
 	if err != nil {
 		return nil, err
@@ -203,6 +432,8 @@ func (c *Client) AddObservation(opt *AddObservationOpt) (*SimpleObservation, err
 		return nil, err
 	}
 
+	c.invalidateObservations()
+
 	return result[0], nil
 }
 
@@ -219,15 +450,12 @@ func (c *Client) GetObservation(id int64) (*FullObservation, error) {
 }
 
 func (c *Client) GetSimpleObservation(id int64) (*SimpleObservation, error) {
-	var result SimpleObservation
-
-	u := c.buildUrl("/observations/%d.json", id)
-	_, err := c.get(u, &result)
+	full, err := c.GetObservation(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	return full.Simple(), nil
 }
 
 type UpdateObservationOpt struct {
@@ -239,12 +467,45 @@ type UpdateObservationOpt struct {
 	Longitude          float64    `json:"longitude,omitempty"`
 	PositionalAccuracy int32      `json:"positional_accuracy,omitempty"`
 	Tags               string     `json:"tag_list,omitempty"`
-	GeoPrivacy         string     `json:"geoprivacy,omitempty"`
+	GeoPrivacy         GeoPrivacy `json:"geoprivacy,omitempty"`
+	ObservedOn         *FuzzyDate `json:"-"`
+	TimeZone           string     `json:"time_zone,omitempty"`
+}
+
+func (o *UpdateObservationOpt) MarshalJSON() ([]byte, error) {
+	type plain UpdateObservationOpt
+	body := struct {
+		*plain
+		ObservedOnString string `json:"observed_on_string,omitempty"`
+		TimeZone         string `json:"time_zone,omitempty"`
+	}{
+		plain:    (*plain)(o),
+		TimeZone: o.TimeZone,
+	}
+	var observedOn FuzzyDate
+	if o.ObservedOnString != nil {
+		observedOn = NewFuzzyDate(*o.ObservedOnString, SecondPrecision)
+	}
+	if o.ObservedOn != nil {
+		observedOn = *o.ObservedOn
+	}
+	if observedOn.IsSet() && !observedOn.Time.IsZero() {
+		value, zone := FormatObservedOnString(observedOn.Time, observedOn.Precision)
+		body.ObservedOnString = value
+		if body.TimeZone == "" {
+			body.TimeZone = zone
+		}
+	}
+	return json.Marshal(&body)
 }
 
 func (c *Client) UpdateObservation(opt *UpdateObservationOpt) error {
 	u := c.buildUrl("/observations/%d.json", opt.Id)
 
+	if err := opt.GeoPrivacy.Validate(); err != nil {
+		return err
+	}
+
 	bodyJson, err := json.Marshal(opt)
 	if err != nil {
 		return err
@@ -260,6 +521,8 @@ func (c *Client) UpdateObservation(opt *UpdateObservationOpt) error {
 		return err
 	}
 
+	c.invalidateObservations()
+
 	return nil
 }
 
@@ -277,6 +540,8 @@ func (c *Client) DeleteObservation(id int64) error {
 		return err
 	}
 
+	c.invalidateObservations()
+
 	return nil
 }
 
@@ -0,0 +1,247 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// apiObservationFieldValue is an observation field value as the node API
+// returns them, the value being a number or a string depending on the
+// field's datatype.
+type apiObservationFieldValue struct {
+	Id       int64                    `json:"id"`
+	Uuid     string                   `json:"uuid"`
+	FieldId  int64                    `json:"field_id"`
+	Name     string                   `json:"name"`
+	Datatype ObservationFieldDatatype `json:"datatype"`
+	Value    interface{}              `json:"value"`
+	UserId   int64                    `json:"user_id"`
+}
+
+// apiObservation decodes an observation from the node API, which names
+// and shapes some fields differently, so it can be returned as the same
+// FullObservation the Rails API gives.
+type apiObservation struct {
+	FullObservation
+	Location         string                      `json:"location"`
+	PrivateLocation  string                      `json:"private_location"`
+	ApiCreatedAt     time.Time                   `json:"created_at"`
+	ApiUpdatedAt     time.Time                   `json:"updated_at"`
+	TimeObservedAt   *time.Time                  `json:"time_observed_at"`
+	ObservedTimeZone string                      `json:"observed_time_zone"`
+	LicenseCode      string                      `json:"license_code"`
+	Ofvs             []*apiObservationFieldValue `json:"ofvs"`
+}
+
+func parseLocation(s string) (lat float64, lng float64, ok bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lng, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+func (a *apiObservation) full() *FullObservation {
+	o := &a.FullObservation
+
+	if lat, lng, ok := parseLocation(a.Location); ok {
+		o.Latitude, o.Longitude = lat, lng
+	}
+	if lat, lng, ok := parseLocation(a.PrivateLocation); ok {
+		o.PrivateLatitude, o.PrivateLongitude = lat, lng
+	}
+	o.CreatedAt = a.ApiCreatedAt.UTC()
+	o.UpdatedAt = a.ApiUpdatedAt.UTC()
+	if a.TimeObservedAt != nil {
+		o.TimeObservedAtUtc = a.TimeObservedAt.UTC()
+	}
+	if o.ZicTimeZone == "" {
+		o.ZicTimeZone = a.ObservedTimeZone
+	}
+	if rails, ok := ianaTimeZones[a.ObservedTimeZone]; ok && o.TimeZone == "" {
+		o.TimeZone = rails
+	}
+	if o.License == "" {
+		o.License = a.LicenseCode
+	}
+	if o.User != nil {
+		o.UserId = o.User.Id
+		o.UserLogin = o.User.Login
+	}
+	if o.Taxon != nil && o.TaxonId == 0 {
+		o.TaxonId = o.Taxon.Id
+	}
+
+	for _, v := range a.Ofvs {
+		value := ""
+		switch typed := v.Value.(type) {
+		case string:
+			value = typed
+		case float64:
+			value = strconv.FormatFloat(typed, 'f', -1, 64)
+		case nil:
+		default:
+			value = fmt.Sprintf("%v", typed)
+		}
+		o.ObservationFieldValues = append(o.ObservationFieldValues, &ObservationFieldValue{
+			Id:                 v.Id,
+			Uuid:               v.Uuid,
+			ObservationId:      o.Id,
+			ObservationFieldId: v.FieldId,
+			Value:              value,
+			UserId:             v.UserId,
+			ObservationField: &ObservationFieldDefinition{
+				Id:       v.FieldId,
+				Name:     v.Name,
+				Datatype: v.Datatype,
+			},
+		})
+	}
+
+	return o
+}
+
+// The node API accepts up to 200 ids per search.
+const observationIdsChunkSize = 200
+
+type ObservationsByIds struct {
+	Observations map[int64]*FullObservation
+	// Missing are the ids that weren't returned, usually because the
+	// observation was deleted or the user can't see it.
+	Missing []int64
+}
+
+func (c *Client) getObservationsChunk(ctx context.Context, ids []int64) ([]*FullObservation, error) {
+	var result []*apiObservation
+
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.FormatInt(id, 10)
+	}
+
+	u := c.buildApiUrl("/observations?per_page=%d&id=%s", len(ids), strings.Join(strs, ","))
+	_, err := c.getApiContext(ctx, u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting observations: %v", err)
+	}
+
+	observations := make([]*FullObservation, len(result))
+	for i, o := range result {
+		observations[i] = o.full()
+	}
+
+	return observations, nil
+}
+
+// GetObservationsByIds fetches observations by id, several hundred at a
+// time and using workers concurrent requests, sharing the client's rate
+// limiter. The first error cancels the remaining requests.
+func (c *Client) GetObservationsByIds(ctx context.Context, ids []int64, workers int) (*ObservationsByIds, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	chunks := make(chan []int64)
+	results := make(chan []*FullObservation)
+	errs := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		defer close(chunks)
+		for i := 0; i < len(unique); i += observationIdsChunkSize {
+			end := i + observationIdsChunkSize
+			if end > len(unique) {
+				end = len(unique)
+			}
+			select {
+			case chunks <- unique[i:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				observations, err := c.getObservationsChunk(ctx, chunk)
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					cancel()
+					return
+				}
+				select {
+				case results <- observations:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	found := &ObservationsByIds{
+		Observations: make(map[int64]*FullObservation),
+		Missing:      make([]int64, 0),
+	}
+	for observations := range results {
+		for _, o := range observations {
+			found.Observations[o.Id] = o
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range unique {
+		if _, ok := found.Observations[id]; !ok {
+			found.Missing = append(found.Missing, id)
+		}
+	}
+	sort.Slice(found.Missing, func(i, j int) bool {
+		return found.Missing[i] < found.Missing[j]
+	})
+
+	return found, nil
+}
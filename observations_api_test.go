@@ -0,0 +1,177 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestGetObservationsByIds(t *testing.T) {
+	var lock sync.Mutex
+	chunks := make([]int, 0)
+
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/observations" {
+			t.Errorf("unexpected request %v", r.URL)
+		}
+		ids := strings.Split(r.URL.Query().Get("id"), ",")
+		if r.URL.Query().Get("per_page") != strconv.Itoa(len(ids)) {
+			t.Errorf("expected per_page to match the %d ids", len(ids))
+		}
+
+		lock.Lock()
+		chunks = append(chunks, len(ids))
+		lock.Unlock()
+
+		// Every seventh observation has been deleted.
+		results := make([]string, 0)
+		for _, id := range ids {
+			if n, _ := strconv.Atoi(id); n%7 != 0 {
+				results = append(results, fmt.Sprintf(`{"id":%s,"location":"45.5,-122.6"}`, id))
+			}
+		}
+		fmt.Fprintf(w, `{"total_results":%d,"page":1,"per_page":%d,"results":[%s]}`, len(results), len(ids), strings.Join(results, ","))
+	}))
+	defer server.Close()
+
+	ids := make([]int64, 0)
+	for i := int64(450); i >= 1; i-- {
+		ids = append(ids, i)
+	}
+	// Duplicates are only fetched once.
+	ids = append(ids, 1, 2, 3)
+
+	found, err := c.GetObservationsByIds(context.Background(), ids, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, n := range chunks {
+		if n > 200 {
+			t.Errorf("expected chunks of at most 200, got %d", n)
+		}
+		total += n
+	}
+	if len(chunks) != 3 || total != 450 {
+		t.Errorf("expected 450 ids in 3 requests, got %v", chunks)
+	}
+
+	if len(found.Observations) != 450-64 {
+		t.Errorf("expected %d observations, got %d", 450-64, len(found.Observations))
+	}
+	if o := found.Observations[1]; o == nil || o.Latitude != 45.5 || o.Longitude != -122.6 {
+		t.Errorf("expected observation 1 with its location, got %+v", o)
+	}
+	if len(found.Missing) != 64 || found.Missing[0] != 7 || found.Missing[63] != 448 {
+		t.Errorf("expected the deleted observations in order, got %v", found.Missing)
+	}
+	for i := 1; i < len(found.Missing); i++ {
+		if found.Missing[i] <= found.Missing[i-1] {
+			t.Errorf("expected Missing sorted, got %v", found.Missing)
+			break
+		}
+	}
+
+	empty, err := c.GetObservationsByIds(context.Background(), nil, 3)
+	if err != nil || len(empty.Observations) != 0 || len(empty.Missing) != 0 {
+		t.Errorf("expected nothing for no ids, got %+v, %v", empty, err)
+	}
+}
+
+func TestGetObservationsByIdsCancelsOnError(t *testing.T) {
+	var requests, finished int32
+
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if strings.HasPrefix(r.URL.Query().Get("id"), "1,") {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		// Hold every other chunk until the request is cancelled.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			atomic.AddInt32(&finished, 1)
+			w.Write([]byte(`{"total_results":0,"page":1,"per_page":0,"results":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	ids := make([]int64, 0)
+	for i := int64(1); i <= 1000; i++ {
+		ids = append(ids, i)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.GetObservationsByIds(context.Background(), ids, 2)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the failing chunk's error")
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatalf("requests in flight weren't cancelled")
+	}
+
+	if atomic.LoadInt32(&finished) != 0 {
+		t.Errorf("expected every request in flight to be cancelled")
+	}
+	if n := atomic.LoadInt32(&requests); n >= 5 {
+		t.Errorf("expected the remaining chunks not to be requested, got %d requests", n)
+	}
+}
+
+func TestApiObservationFull(t *testing.T) {
+	body := `{
+		"id": 1,
+		"location": "45.5,-122.6",
+		"private_location": "45.51234,-122.65432",
+		"observed_time_zone": "America/Los_Angeles",
+		"license_code": "cc-by",
+		"user": {"id": 5, "login": "jacob"},
+		"ofvs": [
+			{"id": 9, "field_id": 3, "name": "Count", "datatype": "numeric", "value": 12},
+			{"id": 10, "field_id": 4, "name": "Notes", "datatype": "text", "value": "by the creek"},
+			{"id": 11, "field_id": 6, "name": "Empty", "datatype": "text", "value": null}
+		]
+	}`
+
+	var a apiObservation
+	if err := json.Unmarshal([]byte(body), &a); err != nil {
+		t.Fatal(err)
+	}
+	o := a.full()
+
+	if o.Latitude != 45.5 || o.Longitude != -122.6 || o.PrivateLatitude != 45.51234 || o.PrivateLongitude != -122.65432 {
+		t.Errorf("unexpected coordinates %+v", o)
+	}
+	if o.ZicTimeZone != "America/Los_Angeles" || o.UserId != 5 || o.UserLogin != "jacob" {
+		t.Errorf("unexpected time zone or user %+v", o)
+	}
+
+	expected := []string{"12", "by the creek", ""}
+	if len(o.ObservationFieldValues) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(o.ObservationFieldValues))
+	}
+	for i, v := range o.ObservationFieldValues {
+		if v.Value != expected[i] || v.ObservationId != 1 {
+			t.Errorf("value %d: expected %q, got %+v", i, expected[i], v)
+		}
+	}
+	if n, err := o.ObservationFieldValues[0].Typed(); err != nil || n != 12.0 {
+		t.Errorf("expected the numeric field to convert, got %v, %v", n, err)
+	}
+}
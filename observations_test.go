@@ -0,0 +1,179 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestGetObservationsSplitsAcrossAntimeridian(t *testing.T) {
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("page") != "2" {
+			t.Errorf("expected page 2 of each half, got %v", r.URL)
+		}
+		w.Header().Set("X-Page", "2")
+		w.Header().Set("X-Per-Page", "2")
+		switch {
+		case q.Get("swlng") == "170" && q.Get("nelng") == "180":
+			w.Header().Set("X-Total-Entries", "4")
+			w.Write([]byte(`[{"id":103},{"id":104}]`))
+		case q.Get("swlng") == "-180" && q.Get("nelng") == "-170":
+			w.Header().Set("X-Total-Entries", "3")
+			w.Write([]byte(`[{"id":203}]`))
+		default:
+			t.Errorf("unexpected rectangle %v", r.URL)
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	page := 2
+	opt := &GetObservationsOpt{
+		Page: &page,
+		Rectangle: &Rectangle{
+			Southwest: Location{Longitude: 170, Latitude: -20},
+			Northeast: Location{Longitude: -170, Latitude: -10},
+		},
+	}
+
+	expected := []int64{103, 104, 203}
+
+	observations, err := c.GetObservations(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := observations.Paging; p == nil || p.TotalEntries != 7 || p.Page != 2 || p.PerPage != 2 {
+		t.Errorf("expected the totals of both halves, got %+v", p)
+	}
+	ids := make([]int64, 0)
+	for _, o := range observations.Observations {
+		ids = append(ids, o.Id)
+	}
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	streamed := make([]int64, 0)
+	p, err := c.StreamObservations(context.Background(), opt, func(o *SimpleObservation) error {
+		streamed = append(streamed, o.Id)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.TotalEntries != 7 {
+		t.Errorf("expected the totals of both halves, got %+v", p)
+	}
+	if fmt.Sprint(streamed) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, streamed)
+	}
+}
+
+func TestFetchObservationPagesAcrossAntimeridian(t *testing.T) {
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		// The west half has three observations, the east half one.
+		base, total := 100, 3
+		if q.Get("swlng") == "-180" {
+			base, total = 200, 1
+		}
+		w.Header().Set("X-Total-Entries", strconv.Itoa(total))
+		w.Header().Set("X-Page", strconv.Itoa(page))
+		w.Header().Set("X-Per-Page", "2")
+		if page == 1 && total > 1 {
+			fmt.Fprintf(w, `[{"id":%d},{"id":%d}]`, base+1, base+2)
+		} else if page == 1 {
+			fmt.Fprintf(w, `[{"id":%d}]`, base+1)
+		} else {
+			fmt.Fprintf(w, `[{"id":%d}]`, base+3)
+		}
+	}))
+	defer server.Close()
+
+	opt := &GetObservationsOpt{
+		Rectangle: &Rectangle{
+			Southwest: Location{Longitude: 170, Latitude: -20},
+			Northeast: Location{Longitude: -170, Latitude: -10},
+		},
+	}
+
+	all, err := c.GetAllObservations(context.Background(), opt, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int64{101, 102, 103, 201}
+	if len(all) != len(expected) {
+		t.Fatalf("expected %v, got %d observations", expected, len(all))
+	}
+	for i, o := range all {
+		if o.Id != expected[i] {
+			t.Errorf("observation %d: expected %d, got %d", i, expected[i], o.Id)
+		}
+	}
+}
+
+func TestTryParseObservedOnKeepsZone(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"2017-03-04T10:30:00-07:00", "2017-03-04T10:30:00-07:00"},
+		{"2017-03-04 10:30:00 +1000", "2017-03-04T10:30:00+10:00"},
+		{"Sat Mar 04 2017 10:30:00 GMT-0700 (PDT)", "2017-03-04T10:30:00-07:00"},
+		{"2017-03-04 10:30", "2017-03-04T10:30:00Z"},
+		{"2017-03-04", "2017-03-04T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		parsed, err := TryParseObservedOn(test.value)
+		if err != nil {
+			t.Errorf("%s: %v", test.value, err)
+			continue
+		}
+		if actual := parsed.Format(time.RFC3339); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.value, test.expected, actual)
+		}
+	}
+
+	o := &SimpleObservation{
+		ObservedOnString: "2017-03-04 10:30",
+		TimeZone:         "Pacific Time (US & Canada)",
+	}
+	parsed, err := o.TryParseObservedOn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := parsed.Format(time.RFC3339); actual != "2017-03-04T10:30:00-08:00" {
+		t.Errorf("expected the observation's zone, got %s", actual)
+	}
+}
+
+func TestStreamObservationsToStopsWhenCancelled(t *testing.T) {
+	c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1},{"id":2},{"id":3}]`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan *SimpleObservation)
+
+	go func() {
+		<-ch
+		cancel()
+	}()
+
+	_, err := c.StreamObservationsTo(ctx, &GetObservationsOpt{}, ch)
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package gonaturalist
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type RequestEvent struct {
+	// Id is unique per request so observers can correlate events.
+	Id       uint64
+	Method   string
+	Url      string
+	Endpoint string
+	Started  time.Time
+}
+
+type ResponseEvent struct {
+	Request    *RequestEvent
+	StatusCode int
+	Bytes      int64
+	Paging     *PageHeaders
+	Elapsed    time.Duration
+	Cached     bool
+	Err        error
+}
+
+type RetryEvent struct {
+	Request    *RequestEvent
+	Attempt    int
+	StatusCode int
+	Wait       time.Duration
+}
+
+type RateLimitEvent struct {
+	Request *RequestEvent
+	Wait    time.Duration
+}
+
+// Observer receives events for every request the client makes.
+// ResponseReceived is called once per request, after any retries, with
+// either a response or an error.
+type Observer interface {
+	RequestStarted(e *RequestEvent)
+	ResponseReceived(e *ResponseEvent)
+	Retrying(e *RetryEvent)
+	RateLimited(e *RateLimitEvent)
+}
+
+type NoopObserver struct {
+}
+
+func (o *NoopObserver) RequestStarted(e *RequestEvent) {
+}
+
+func (o *NoopObserver) ResponseReceived(e *ResponseEvent) {
+}
+
+func (o *NoopObserver) Retrying(e *RetryEvent) {
+}
+
+func (o *NoopObserver) RateLimited(e *RateLimitEvent) {
+}
+
+// AddObserver should be called before the client is used.
+func (c *Client) AddObserver(o Observer) {
+	c.observers = append(c.observers, o)
+}
+
+var endpointSegments = map[string]bool{
+	"v1":                            true,
+	"oauth":                         true,
+	"token":                         true,
+	"observations":                  true,
+	"observations.json":             true,
+	"observation":                   true,
+	"observation_fields":            true,
+	"observation_fields.json":       true,
+	"observation_field_values":      true,
+	"observation_field_values.json": true,
+	"projects":                      true,
+	"projects.json":                 true,
+	"places":                        true,
+	"places.json":                   true,
+	"users":                         true,
+	"user":                          true,
+	"edit.json":                     true,
+	"comments":                      true,
+	"comments.json":                 true,
+	"annotations":                   true,
+	"annotations.json":              true,
+	"annotation":                    true,
+	"controlled_terms":              true,
+	"controlled_terms.json":         true,
+	"votes":                         true,
+	"vote":                          true,
+	"unvote":                        true,
+	"quality":                       true,
+	"join.json":                     true,
+	"leave.json":                    true,
+	"search.json":                   true,
+	"autocomplete":                  true,
+	"nearby":                        true,
+	"species_counts":                true,
+	"observers":                     true,
+	"identifiers":                   true,
+	"histogram":                     true,
+}
+
+var metricSegments = regexp.MustCompile(`^(wild|location|date|evidence|recent|subject)(\.json)?$`)
+
+// identifierCollections are the segments followed by an id, slug or login.
+var identifierCollections = map[string]bool{
+	"observations":             true,
+	"observation":              true,
+	"observation_fields":       true,
+	"observation_field_values": true,
+	"projects":                 true,
+	"places":                   true,
+	"comments":                 true,
+	"annotations":              true,
+	"annotation":               true,
+	"user":                     true,
+}
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+(,[0-9]+)*$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	slugSegment    = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+func isIdentifierSegment(previous, segment string) bool {
+	if numericSegment.MatchString(segment) || uuidSegment.MatchString(segment) {
+		return true
+	}
+	if endpointSegments[segment+".json"] || endpointSegments[segment] || metricSegments.MatchString(segment) {
+		return false
+	}
+	return identifierCollections[previous] && slugSegment.MatchString(segment)
+}
+
+// EndpointTemplate reduces a request url to its route, replacing ids,
+// slugs and logins with placeholders, e.g. /observations/{id}.json. Only
+// numeric ids, UUIDs and slugs following a collection are replaced, any
+// other segment is kept. This keeps the number of distinct endpoints small
+// for metrics and tracing.
+func EndpointTemplate(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "unknown"
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	previous := ""
+	for i, segment := range segments {
+		name := strings.TrimSuffix(segment, ".json")
+		if name != "" && isIdentifierSegment(previous, name) {
+			if name != segment {
+				segments[i] = "{id}.json"
+			} else {
+				segments[i] = "{id}"
+			}
+		}
+		previous = segment
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+var requestIds uint64
+
+// requestTracker reports a single request's progress to the callbacks and
+// observers.
+type requestTracker struct {
+	c       *Client
+	event   *RequestEvent
+	attempt int
+}
+
+func (c *Client) startRequest(method, url string) *requestTracker {
+	t := &requestTracker{
+		c: c,
+		event: &RequestEvent{
+			Id:       atomic.AddUint64(&requestIds, 1),
+			Method:   method,
+			Url:      url,
+			Endpoint: EndpointTemplate(url),
+			Started:  time.Now(),
+		},
+		attempt: 1,
+	}
+	for _, o := range c.observers {
+		o.RequestStarted(t.event)
+	}
+	return t
+}
+
+func (t *requestTracker) retrying(status int, wait time.Duration) {
+	for _, o := range t.c.observers {
+		o.Retrying(&RetryEvent{
+			Request:    t.event,
+			Attempt:    t.attempt,
+			StatusCode: status,
+			Wait:       wait,
+		})
+	}
+	t.attempt++
+}
+
+func (t *requestTracker) rateLimited(wait time.Duration) {
+	for _, o := range t.c.observers {
+		o.RateLimited(&RateLimitEvent{
+			Request: t.event,
+			Wait:    wait,
+		})
+	}
+}
+
+func (t *requestTracker) completed(status int, bytes int64, paging *PageHeaders, cached bool, err error) {
+	elapsed := time.Since(t.event.Started)
+	if t.c.callbacks != nil {
+		t.c.callbacks.Completed(t.event.Method, t.event.Url, elapsed, err)
+	}
+	for _, o := range t.c.observers {
+		o.ResponseReceived(&ResponseEvent{
+			Request:    t.event,
+			StatusCode: status,
+			Bytes:      bytes,
+			Paging:     paging,
+			Elapsed:    elapsed,
+			Cached:     cached,
+			Err:        err,
+		})
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
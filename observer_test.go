@@ -0,0 +1,34 @@
+package gonaturalist
+
+import "testing"
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://www.inaturalist.org/observations.json?page=2", "/observations.json"},
+		{"https://www.inaturalist.org/observations/123.json", "/observations/{id}.json"},
+		{"https://www.inaturalist.org/observations/jacob.json", "/observations/{id}.json"},
+		{"https://www.inaturalist.org/observations/123/quality/wild.json", "/observations/{id}/quality/wild.json"},
+		{"https://www.inaturalist.org/projects/my-project/join.json", "/projects/{id}/join.json"},
+		{"https://www.inaturalist.org/projects/user/jacob.json", "/projects/user/{id}.json"},
+		{"https://www.inaturalist.org/places/search.json?q=x", "/places/search.json"},
+		{"https://www.inaturalist.org/users/edit.json", "/users/edit.json"},
+		{"https://www.inaturalist.org/votes/vote/observation/5.json", "/votes/vote/observation/{id}.json"},
+		{"https://www.inaturalist.org/votes/unvote/annotation/0a1b2c3d-4e5f-6071-8293-a4b5c6d7e8f9.json", "/votes/unvote/annotation/{id}.json"},
+		{"https://api.inaturalist.org/v1/places/nearby?nelat=1", "/v1/places/nearby"},
+		{"https://api.inaturalist.org/v1/places/42", "/v1/places/{id}"},
+		{"https://api.inaturalist.org/v1/observations/species_counts", "/v1/observations/species_counts"},
+		{"https://api.inaturalist.org/v1/taxa/autocomplete", "/v1/taxa/autocomplete"},
+		{"https://api.inaturalist.org/v1/taxa/1,2,3", "/v1/taxa/{id}"},
+		{"https://api.inaturalist.org/v1/", "/v1"},
+		{"://", "unknown"},
+	}
+
+	for _, test := range tests {
+		if actual := EndpointTemplate(test.url); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.url, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/conservify/gonaturalist/otelobserver
+
+go 1.21
+
+require (
+	github.com/conservify/gonaturalist v0.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/net v0.0.0-20190522155817-f3200d17e092 // indirect
+	golang.org/x/oauth2 v0.0.0-20190523182746-aaccbc9213b0 // indirect
+	google.golang.org/appengine v1.4.0 // indirect
+)
+
+replace github.com/conservify/gonaturalist => ../
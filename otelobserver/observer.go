@@ -0,0 +1,128 @@
+// Package otelobserver reports gonaturalist requests as OpenTelemetry spans.
+// It lives in its own module so the client library itself does not depend
+// on OpenTelemetry.
+package otelobserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/conservify/gonaturalist"
+)
+
+const instrumentationName = "github.com/conservify/gonaturalist/otelobserver"
+
+// Observer starts a client span for each request, named after the
+// endpoint template, and ends it once the response (or error) arrives.
+// Retries and rate limit waits are recorded as span events.
+type Observer struct {
+	tracer trace.Tracer
+	parent context.Context
+	lock   sync.Mutex
+	spans  map[uint64]trace.Span
+}
+
+// New uses the global tracer provider.
+func New() *Observer {
+	return NewWithTracer(otel.Tracer(instrumentationName), context.Background())
+}
+
+// NewWithTracer starts spans as children of any span in parent.
+func NewWithTracer(tracer trace.Tracer, parent context.Context) *Observer {
+	return &Observer{
+		tracer: tracer,
+		parent: parent,
+		spans:  make(map[uint64]trace.Span),
+	}
+}
+
+func (o *Observer) RequestStarted(e *gonaturalist.RequestEvent) {
+	_, span := o.tracer.Start(o.parent, fmt.Sprintf("%s %s", e.Method, e.Endpoint),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(e.Started),
+		trace.WithAttributes(
+			attribute.String("http.request.method", e.Method),
+			attribute.String("http.route", e.Endpoint),
+			attribute.String("url.full", e.Url),
+		))
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.spans[e.Id] = span
+}
+
+func (o *Observer) span(id uint64, remove bool) trace.Span {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	span, ok := o.spans[id]
+	if !ok {
+		return nil
+	}
+	if remove {
+		delete(o.spans, id)
+	}
+	return span
+}
+
+func (o *Observer) ResponseReceived(e *gonaturalist.ResponseEvent) {
+	span := o.span(e.Request.Id, true)
+	if span == nil {
+		return
+	}
+
+	if e.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", e.StatusCode))
+	}
+	span.SetAttributes(
+		attribute.Int64("http.response.body.size", e.Bytes),
+		attribute.Bool("gonaturalist.cached", e.Cached),
+	)
+	if e.Paging != nil {
+		span.SetAttributes(
+			attribute.Int("gonaturalist.paging.total_entries", e.Paging.TotalEntries),
+			attribute.Int("gonaturalist.paging.page", e.Paging.Page),
+			attribute.Int("gonaturalist.paging.per_page", e.Paging.PerPage),
+		)
+	}
+
+	if e.Err != nil {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	} else if e.StatusCode >= 400 {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", e.StatusCode))
+	}
+
+	span.End(trace.WithTimestamp(e.Request.Started.Add(e.Elapsed)))
+}
+
+func (o *Observer) Retrying(e *gonaturalist.RetryEvent) {
+	span := o.span(e.Request.Id, false)
+	if span == nil {
+		return
+	}
+
+	span.AddEvent("retry", trace.WithAttributes(
+		attribute.Int("gonaturalist.retry.attempt", e.Attempt),
+		attribute.Int("http.response.status_code", e.StatusCode),
+		attribute.Float64("gonaturalist.retry.wait_seconds", e.Wait.Seconds()),
+	))
+}
+
+func (o *Observer) RateLimited(e *gonaturalist.RateLimitEvent) {
+	span := o.span(e.Request.Id, false)
+	if span == nil {
+		return
+	}
+
+	span.AddEvent("rate_limited", trace.WithAttributes(
+		attribute.Float64("gonaturalist.rate_limit.wait_seconds", e.Wait.Seconds()),
+	))
+}
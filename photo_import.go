@@ -0,0 +1,235 @@
+package gonaturalist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type GroupPhotosOpt struct {
+	// MaxInterval is the longest gap between consecutive photos of the
+	// same observation, defaults to DefaultGroupInterval.
+	MaxInterval time.Duration
+	// MaxDistanceKm is how far a photo may be from the first located
+	// photo of the observation, defaults to DefaultGroupDistanceKm.
+	MaxDistanceKm float64
+	// Location is used for photos that don't record their offset,
+	// defaults to time.Local.
+	Location *time.Location
+}
+
+const (
+	DefaultGroupInterval   = 5 * time.Minute
+	DefaultGroupDistanceKm = 0.1
+)
+
+// PhotoObservation is an observation to create from photos, with the
+// fields their metadata could fill in.
+type PhotoObservation struct {
+	Observation *AddObservationOpt
+	Photos      []*PhotoMetadata
+}
+
+func (opt *GroupPhotosOpt) withDefaults() *GroupPhotosOpt {
+	filled := GroupPhotosOpt{}
+	if opt != nil {
+		filled = *opt
+	}
+	if filled.MaxInterval == 0 {
+		filled.MaxInterval = DefaultGroupInterval
+	}
+	if filled.MaxDistanceKm == 0 {
+		filled.MaxDistanceKm = DefaultGroupDistanceKm
+	}
+	if filled.Location == nil {
+		filled.Location = time.Local
+	}
+	return &filled
+}
+
+func firstLocation(photos []*PhotoMetadata) *Location {
+	for _, p := range photos {
+		if p.Location != nil {
+			return p.Location
+		}
+	}
+	return nil
+}
+
+func belongsTo(group []*PhotoMetadata, p *PhotoMetadata, opt *GroupPhotosOpt) bool {
+	last := group[len(group)-1]
+	if last.TakenAt.IsZero() || p.TakenAt.IsZero() {
+		return false
+	}
+	if p.TakenAt.Sub(last.TakenAt) > opt.MaxInterval {
+		return false
+	}
+	if first := firstLocation(group); first != nil && p.Location != nil {
+		if first.DistanceKm(*p.Location) > opt.MaxDistanceKm {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupPhotos orders photos by when they were taken and starts a new
+// observation whenever the gap or distance to the previous photos is too
+// large. Photos without a time each get their own observation.
+func GroupPhotos(photos []*PhotoMetadata, opt *GroupPhotosOpt) []*PhotoObservation {
+	opt = opt.withDefaults()
+
+	sorted := append([]*PhotoMetadata{}, photos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].TakenAt, sorted[j].TakenAt
+		if a.IsZero() != b.IsZero() {
+			return !a.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	groups := make([][]*PhotoMetadata, 0)
+	for _, p := range sorted {
+		if n := len(groups); n > 0 && belongsTo(groups[n-1], p, opt) {
+			groups[n-1] = append(groups[n-1], p)
+		} else {
+			groups = append(groups, []*PhotoMetadata{p})
+		}
+	}
+
+	observations := make([]*PhotoObservation, len(groups))
+	for i, group := range groups {
+		observations[i] = &PhotoObservation{
+			Observation: prefillObservation(group),
+			Photos:      group,
+		}
+	}
+
+	return observations
+}
+
+// prefillObservation uses the earliest time and the most accurate
+// location, widening the accuracy to cover every located photo.
+func prefillObservation(photos []*PhotoMetadata) *AddObservationOpt {
+	o := &AddObservationOpt{}
+
+	if taken := photos[0].TakenAt; !taken.IsZero() {
+		observedOn := NewFuzzyDate(taken, SecondPrecision)
+		o.ObservedOn = &observedOn
+	}
+
+	var best *PhotoMetadata
+	for _, p := range photos {
+		if p.Location == nil {
+			continue
+		}
+		if best == nil || (p.Accuracy > 0 && (best.Accuracy == 0 || p.Accuracy < best.Accuracy)) {
+			best = p
+		}
+	}
+	if best == nil {
+		return o
+	}
+
+	o.Latitude = best.Location.Latitude
+	o.Longitude = best.Location.Longitude
+
+	accuracy := best.Accuracy
+	for _, p := range photos {
+		if p.Location != nil && p != best {
+			accuracy = math.Max(accuracy, best.Location.DistanceKm(*p.Location)*1000+p.Accuracy)
+		}
+	}
+	o.PositionalAccuracy = int32(math.Ceil(accuracy))
+
+	return o
+}
+
+// PreparePhotoObservations reads the metadata of each photo and groups
+// them. Photos without EXIF, including PNG, HEIC and other formats whose
+// metadata isn't read, are kept as observations of their own.
+func PreparePhotoObservations(paths []string, opt *GroupPhotosOpt) ([]*PhotoObservation, error) {
+	opt = opt.withDefaults()
+
+	photos := make([]*PhotoMetadata, 0, len(paths))
+	for _, path := range paths {
+		md, err := ReadPhotoMetadataFile(path, opt.Location)
+		if err == ErrNoExif || err == ErrNotJpeg {
+			md = &PhotoMetadata{Path: path}
+		} else if err != nil {
+			return nil, err
+		}
+		photos = append(photos, md)
+	}
+
+	return GroupPhotos(photos, opt), nil
+}
+
+// AddObservationPhoto uploads a photo and attaches it to an observation.
+func (c *Client) AddObservationPhoto(observationId int64, filename string, r io.Reader) (*ObservationPhoto, error) {
+	u := c.buildUrl("/observation_photos.json")
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	if err := w.WriteField("observation_photo[observation_id]", strconv.FormatInt(observationId, 10)); err != nil {
+		return nil, err
+	}
+	part, err := w.CreateFormFile("file", filepath.Base(filename))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", u, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	var result ObservationPhoto
+	if err := c.execute(req, &result, http.StatusCreated); err != nil {
+		return nil, err
+	}
+
+	c.invalidateObservations()
+
+	return &result, nil
+}
+
+func (c *Client) AddObservationPhotoFile(observationId int64, path string) (*ObservationPhoto, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return c.AddObservationPhoto(observationId, path, f)
+}
+
+// AddPhotoObservation creates the observation and then uploads its photos.
+func (c *Client) AddPhotoObservation(po *PhotoObservation) (*SimpleObservation, error) {
+	observation, err := c.AddObservation(po.Observation)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range po.Photos {
+		if _, err := c.AddObservationPhotoFile(observation.Id, p.Path); err != nil {
+			return observation, fmt.Errorf("Uploading %s: %v", p.Path, err)
+		}
+	}
+
+	return observation, nil
+}
@@ -0,0 +1,234 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroupPhotos(t *testing.T) {
+	start := time.Date(2019, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes float64) time.Time {
+		return start.Add(time.Duration(minutes * float64(time.Minute)))
+	}
+	here := &Location{Latitude: 45, Longitude: -122}
+	near := &Location{Latitude: 45.0005, Longitude: -122}
+	far := &Location{Latitude: 45.01, Longitude: -122}
+
+	tests := []struct {
+		name     string
+		photos   []*PhotoMetadata
+		expected string
+	}{
+		{
+			name: "close together",
+			photos: []*PhotoMetadata{
+				{Path: "a", TakenAt: at(0), Location: here},
+				{Path: "b", TakenAt: at(1), Location: near},
+				{Path: "c", TakenAt: at(2)},
+			},
+			expected: "a b c",
+		},
+		{
+			name: "sorted by time",
+			photos: []*PhotoMetadata{
+				{Path: "c", TakenAt: at(2)},
+				{Path: "a", TakenAt: at(0)},
+				{Path: "b", TakenAt: at(1)},
+			},
+			expected: "a b c",
+		},
+		{
+			name: "gap between consecutive photos",
+			photos: []*PhotoMetadata{
+				{Path: "a", TakenAt: at(0)},
+				{Path: "b", TakenAt: at(4)},
+				{Path: "c", TakenAt: at(8)},
+				{Path: "d", TakenAt: at(14)},
+			},
+			expected: "a b c|d",
+		},
+		{
+			name: "too far from the first located photo",
+			photos: []*PhotoMetadata{
+				{Path: "a", TakenAt: at(0), Location: here},
+				{Path: "b", TakenAt: at(1), Location: far},
+				{Path: "c", TakenAt: at(2), Location: far},
+			},
+			expected: "a|b c",
+		},
+		{
+			name: "photos without a time on their own, last",
+			photos: []*PhotoMetadata{
+				{Path: "x"},
+				{Path: "a", TakenAt: at(0)},
+				{Path: "y"},
+				{Path: "b", TakenAt: at(1)},
+			},
+			expected: "a b|x|y",
+		},
+		{
+			name:     "none",
+			photos:   []*PhotoMetadata{},
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		groups := GroupPhotos(test.photos, nil)
+
+		names := make([]string, len(groups))
+		for i, g := range groups {
+			paths := make([]string, len(g.Photos))
+			for j, p := range g.Photos {
+				paths[j] = p.Path
+			}
+			names[i] = strings.Join(paths, " ")
+		}
+		if actual := strings.Join(names, "|"); actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestGroupPhotosPrefill(t *testing.T) {
+	start := time.Date(2019, 6, 1, 10, 0, 0, 0, time.UTC)
+	here := &Location{Latitude: 45, Longitude: -122}
+	near := &Location{Latitude: 45.0005, Longitude: -122}
+
+	tests := []struct {
+		name      string
+		photos    []*PhotoMetadata
+		location  *Location
+		accuracy  int32
+		sentField bool
+	}{
+		{
+			name: "most accurate location widened to cover the rest",
+			photos: []*PhotoMetadata{
+				{TakenAt: start, Location: near, Accuracy: 30},
+				{TakenAt: start.Add(time.Minute), Location: here, Accuracy: 5},
+			},
+			location:  here,
+			accuracy:  86,
+			sentField: true,
+		},
+		{
+			name: "unknown accuracy",
+			photos: []*PhotoMetadata{
+				{TakenAt: start, Location: here},
+			},
+			location: here,
+		},
+		{
+			name: "no location",
+			photos: []*PhotoMetadata{
+				{TakenAt: start},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		groups := GroupPhotos(test.photos, nil)
+		if len(groups) != 1 {
+			t.Errorf("%s: expected one group, got %d", test.name, len(groups))
+			continue
+		}
+		o := groups[0].Observation
+
+		if o.ObservedOn == nil || !o.ObservedOn.Time.Equal(start) {
+			t.Errorf("%s: expected observed on %v, got %v", test.name, start, o.ObservedOn)
+		}
+		if test.location != nil && (o.Latitude != test.location.Latitude || o.Longitude != test.location.Longitude) {
+			t.Errorf("%s: expected location %v, got %v, %v", test.name, *test.location, o.Latitude, o.Longitude)
+		}
+		if o.PositionalAccuracy != test.accuracy {
+			t.Errorf("%s: expected accuracy %d, got %d", test.name, test.accuracy, o.PositionalAccuracy)
+		}
+
+		b, err := json.Marshal(o)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var sent map[string]interface{}
+		if err := json.Unmarshal(b, &sent); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if _, ok := sent["positional_accuracy"]; ok != test.sentField {
+			t.Errorf("%s: expected positional_accuracy sent %v in %s", test.name, test.sentField, b)
+		}
+		if _, ok := sent["latitude"]; ok != (test.location != nil) {
+			t.Errorf("%s: unexpected latitude in %s", test.name, b)
+		}
+	}
+}
+
+func TestAddObservationOptOmitsAccuracyWithoutLocation(t *testing.T) {
+	o := &AddObservationOpt{PositionalAccuracy: 10}
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "positional_accuracy") {
+		t.Errorf("expected no accuracy without a location, got %s", b)
+	}
+}
+
+func TestPreparePhotoObservationsKeepsOtherFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonaturalist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exif := buildTiff(
+		[]testTiffEntry{{tag: tagExifIfd, ifd: 1}},
+		[]testTiffEntry{asciiEntry(tagDateTimeOriginal, "2019:06:01 14:31:10"), asciiEntry(tagOffsetTimeOriginal, "+00:00")},
+	)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"a.jpg", wrapJpeg(exif)},
+		{"b.jpg", wrapJpeg(exif)},
+		{"c.png", []byte("\x89PNG\r\n\x1a\n")},
+		{"d.heic", []byte("\x00\x00\x00\x18ftypheic")},
+		{"e.jpg", []byte{0xff, 0xd8, 0xff, 0xda}},
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(dir, f.name)
+		if err := ioutil.WriteFile(paths[i], f.data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := PreparePhotoObservations(paths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		group := make([]string, len(g.Photos))
+		for j, p := range g.Photos {
+			group[j] = filepath.Base(p.Path)
+		}
+		names[i] = strings.Join(group, " ")
+	}
+	if actual := strings.Join(names, "|"); actual != "a.jpg b.jpg|c.png|d.heic|e.jpg" {
+		t.Errorf("expected other formats as observations of their own, got %q", actual)
+	}
+
+	paths = append(paths, filepath.Join(dir, "missing.jpg"))
+	if _, err := PreparePhotoObservations(paths, nil); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -23,6 +23,33 @@ type SimplePlace struct {
 	SwLon         float64   `json:"swlng,string"`
 	NeLat         float64   `json:"nelat,string"`
 	NeLon         float64   `json:"nelng,string"`
+	AdminLevel    *int32    `json:"admin_level"`
+	BboxArea      float64   `json:"bbox_area"`
+
+	Location         string           `json:"location"`
+	AncestorPlaceIds []int64          `json:"ancestor_place_ids"`
+	BoundingBox      *GeoJsonGeometry `json:"bounding_box_geojson"`
+	Geometry         *GeoJsonGeometry `json:"geometry_geojson"`
+}
+
+// normalize fills in the coordinates and bounding box of places returned by
+// the node API, which uses a "lat,lng" location and GeoJSON box instead.
+func (p *SimplePlace) normalize() {
+	if p.Latitude == 0 && p.Longitude == 0 && p.Location != "" {
+		fmt.Sscanf(p.Location, "%f,%f", &p.Latitude, &p.Longitude)
+	}
+	if p.SwLat == 0 && p.SwLon == 0 && p.NeLat == 0 && p.NeLon == 0 && p.BoundingBox != nil {
+		if r, err := p.BoundingBox.Bounds(); err == nil {
+			p.SwLat = r.Southwest.Latitude
+			p.SwLon = r.Southwest.Longitude
+			p.NeLat = r.Northeast.Latitude
+			p.NeLon = r.Northeast.Longitude
+		}
+	}
+}
+
+func (p *SimplePlace) HasGeometry() bool {
+	return p.Geometry != nil
 }
 
 func (p *SimplePlace) Rectangle() (r Rectangle, err error) {
@@ -66,6 +93,8 @@ type PlacesPage struct {
 
 type GetPlacesOpt struct {
 	Page      *int
+	PerPage   *int
+	Query     *string
 	Longitude *float64
 	Latitude  *float64
 }
@@ -79,6 +108,12 @@ func (c *Client) GetPlaces(opt *GetPlacesOpt) (*PlacesPage, error) {
 		if opt.Page != nil {
 			v.Set("page", strconv.Itoa(*opt.Page))
 		}
+		if opt.PerPage != nil {
+			v.Set("per_page", strconv.Itoa(*opt.PerPage))
+		}
+		if opt.Query != nil {
+			v.Set("q", *opt.Query)
+		}
 		if opt.Longitude != nil {
 			v.Set("longitude", fmt.Sprintf("%v", *opt.Longitude))
 		}
@@ -99,3 +134,140 @@ func (c *Client) GetPlaces(opt *GetPlacesOpt) (*PlacesPage, error) {
 		Paging: p,
 	}, nil
 }
+
+func (c *Client) SearchPlaces(query string, page *int) (*PlacesPage, error) {
+	var result []*SimplePlace
+
+	v := url.Values{}
+	v.Set("q", query)
+	if page != nil {
+		v.Set("page", strconv.Itoa(*page))
+	}
+
+	u := c.buildUrl("/places/search.json?%s", v.Encode())
+	p, err := c.get(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error searching places: %v", err)
+	}
+
+	return &PlacesPage{
+		Places: result,
+		Paging: p,
+	}, nil
+}
+
+func (c *Client) AutocompletePlaces(query string) (*PlacesPage, error) {
+	var result []*SimplePlace
+
+	v := url.Values{}
+	v.Set("q", query)
+
+	u := c.buildApiUrl("/places/autocomplete?%s", v.Encode())
+	p, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error autocompleting places: %v", err)
+	}
+
+	for _, place := range result {
+		place.normalize()
+	}
+
+	return &PlacesPage{
+		Places: result,
+		Paging: p,
+	}, nil
+}
+
+type NearbyPlaces struct {
+	Standard  []*SimplePlace `json:"standard"`
+	Community []*SimplePlace `json:"community"`
+}
+
+// GetNearbyPlaces returns the standard (curated) and community places
+// that overlap the rectangle, optionally filtered by name.
+func (c *Client) GetNearbyPlaces(r Rectangle, name *string) (*NearbyPlaces, error) {
+	var result NearbyPlaces
+
+	v := url.Values{}
+	v.Set("swlng", fmt.Sprintf("%v", r.Southwest.Longitude))
+	v.Set("swlat", fmt.Sprintf("%v", r.Southwest.Latitude))
+	v.Set("nelng", fmt.Sprintf("%v", r.Northeast.Longitude))
+	v.Set("nelat", fmt.Sprintf("%v", r.Northeast.Latitude))
+	if name != nil {
+		v.Set("name", *name)
+	}
+
+	u := c.buildApiUrl("/places/nearby?%s", v.Encode())
+	_, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting nearby places: %v", err)
+	}
+
+	for _, place := range result.Standard {
+		place.normalize()
+	}
+	for _, place := range result.Community {
+		place.normalize()
+	}
+
+	return &result, nil
+}
+
+// GetPlace fetches a place by id or slug.
+func (c *Client) GetPlace(id interface{}) (*SimplePlace, error) {
+	var result SimplePlace
+
+	u := c.buildUrl("/places/%v.json", id)
+	_, err := c.get(u, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// NoGeometryError is returned when a place doesn't exist or has no
+// boundary, as opposed to the request failing.
+type NoGeometryError struct {
+	PlaceId int64
+	Missing bool
+}
+
+func (e *NoGeometryError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("No such place: %d", e.PlaceId)
+	}
+	return fmt.Sprintf("Place %d has no geometry", e.PlaceId)
+}
+
+// GetPlaceWithGeometry fetches a place from the node API, which includes
+// the place's boundary.
+func (c *Client) GetPlaceWithGeometry(id int64) (*SimplePlace, error) {
+	var result []*SimplePlace
+
+	u := c.buildApiUrl("/places/%d", id)
+	_, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, &NoGeometryError{PlaceId: id, Missing: true}
+	}
+
+	place := result[0]
+	place.normalize()
+
+	return place, nil
+}
+
+func (c *Client) GetPlaceGeometry(id int64) (*GeoJsonGeometry, error) {
+	place, err := c.GetPlaceWithGeometry(id)
+	if err != nil {
+		return nil, err
+	}
+	if place.Geometry == nil {
+		return nil, &NoGeometryError{PlaceId: id}
+	}
+
+	return place.Geometry, nil
+}
@@ -2,6 +2,7 @@ package gonaturalist
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"time"
@@ -90,11 +91,25 @@ func (c *Client) GetProjectMembers(id interface{}) error {
 }
 
 func (c *Client) JoinProject(id interface{}) error {
-	return fmt.Errorf("Unimplemented")
+	u := c.buildUrl("/projects/%v/join.json", id)
+	if err := c.executeEmpty("POST", u, http.StatusCreated); err != nil {
+		return err
+	}
+
+	c.invalidateProject(id)
+
+	return nil
 }
 
 func (c *Client) LeaveProject(id interface{}) error {
-	return fmt.Errorf("Unimplemented")
+	u := c.buildUrl("/projects/%v/leave.json", id)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateProject(id)
+
+	return nil
 }
 
 func (c *Client) AddObservationToProject(projectId interface{}, observationId interface{}) error {
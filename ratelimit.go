@@ -0,0 +1,116 @@
+package gonaturalist
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RateLimiter is a token bucket shared by every request a client makes,
+// including those made concurrently. iNaturalist asks API users to stay
+// around one request per second.
+type RateLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows perSecond requests on average with bursts of up to
+// burst. A perSecond of zero or less means no limit.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if !(perSecond > 0) {
+		perSecond = 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// using it.
+func (l *RateLimiter) reserve() time.Duration {
+	if l.rate == 0 {
+		return 0
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+func (l *RateLimiter) cancel() {
+	if l.rate == 0 {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.tokens++
+}
+
+// Wait blocks until a request may be made, returning how long it waited.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	wait := l.reserve()
+	if wait == 0 {
+		return 0, nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.cancel()
+		return 0, err
+	}
+	return wait, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetRateLimiter should be called before the client is used, nil removes
+// the limit.
+func (c *Client) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+func (c *Client) waitForLimiter(ctx context.Context, t *requestTracker) error {
+	if c.limiter == nil {
+		return nil
+	}
+	wait, err := c.limiter.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		t.rateLimited(wait)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package gonaturalist
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	tests := []struct {
+		perSecond float64
+		burst     int
+		waits     []time.Duration
+	}{
+		{0, 1, []time.Duration{0, 0, 0}},
+		{-1, 1, []time.Duration{0, 0, 0}},
+		{math.NaN(), 1, []time.Duration{0, 0, 0}},
+		{1, 2, []time.Duration{0, 0, time.Second}},
+		{10, 1, []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		l := NewRateLimiter(test.perSecond, test.burst)
+		for i, expected := range test.waits {
+			wait := l.reserve()
+			if d := wait - expected; d < -10*time.Millisecond || d > 10*time.Millisecond {
+				t.Errorf("%v/s burst %d, request %d: expected to wait %v, got %v", test.perSecond, test.burst, i, expected, wait)
+			}
+		}
+	}
+}
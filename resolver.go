@@ -0,0 +1,134 @@
+package gonaturalist
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultResolverPrecision = 5
+)
+
+// PlaceResolver finds the standard places containing a location. Candidate
+// places are fetched once per geohash cell and boundaries once per place, so
+// resolving many nearby locations only makes a handful of requests.
+type PlaceResolver struct {
+	client     *Client
+	precision  int
+	lock       sync.Mutex
+	cells      map[string][]*SimplePlace
+	geometries map[int64]*GeoJsonGeometry
+}
+
+func NewPlaceResolver(client *Client) *PlaceResolver {
+	return NewPlaceResolverWithPrecision(client, DefaultResolverPrecision)
+}
+
+func NewPlaceResolverWithPrecision(client *Client, precision int) *PlaceResolver {
+	return &PlaceResolver{
+		client:     client,
+		precision:  precision,
+		cells:      make(map[string][]*SimplePlace),
+		geometries: make(map[int64]*GeoJsonGeometry),
+	}
+}
+
+func (r *PlaceResolver) candidates(cell string) ([]*SimplePlace, error) {
+	r.lock.Lock()
+	places, ok := r.cells[cell]
+	r.lock.Unlock()
+	if ok {
+		return places, nil
+	}
+
+	bounds, err := GeohashBounds(cell)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby, err := r.client.GetNearbyPlaces(bounds, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	r.cells[cell] = nearby.Standard
+	r.lock.Unlock()
+
+	return nearby.Standard, nil
+}
+
+func (r *PlaceResolver) geometry(place *SimplePlace) (*GeoJsonGeometry, error) {
+	if place.Geometry != nil {
+		return place.Geometry, nil
+	}
+
+	r.lock.Lock()
+	g, ok := r.geometries[place.Id]
+	r.lock.Unlock()
+	if ok {
+		return g, nil
+	}
+
+	g, err := r.client.GetPlaceGeometry(place.Id)
+	if _, missing := err.(*NoGeometryError); missing {
+		// Remembered as nil so the place isn't fetched again.
+		g = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	r.geometries[place.Id] = g
+	r.lock.Unlock()
+
+	return g, nil
+}
+
+// Resolve returns the places containing the location, broadest first:
+// places with an admin level (country, state, county, ...) ordered by that
+// level, followed by others such as protected areas.
+func (r *PlaceResolver) Resolve(l Location) ([]*SimplePlace, error) {
+	candidates, err := r.candidates(l.Geohash(r.precision))
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates without a usable boundary are skipped rather than making
+	// every location in the cell unresolvable, only failed requests are
+	// errors.
+	containing := make([]*SimplePlace, 0)
+	for _, place := range candidates {
+		g, err := r.geometry(place)
+		if err != nil {
+			return nil, err
+		}
+		if g == nil {
+			continue
+		}
+		inside, err := g.Contains(l)
+		if err != nil {
+			continue
+		}
+		if inside {
+			containing = append(containing, place)
+		}
+	}
+
+	sort.SliceStable(containing, func(i, j int) bool {
+		a, b := containing[i].AdminLevel, containing[j].AdminLevel
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	return containing, nil
+}
+
+func (r *PlaceResolver) ResolveObservation(o *SimpleObservation) ([]*SimplePlace, error) {
+	return r.Resolve(Location{
+		Longitude: o.Longitude,
+		Latitude:  o.Latitude,
+	})
+}
@@ -0,0 +1,69 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const squarePolygon = `{"type":"Polygon","coordinates":[[[10,50],[11,50],[11,51],[10,51],[10,50]]]}`
+
+func TestResolveSkipsPlacesWithoutGeometry(t *testing.T) {
+	requests := make(map[string]int)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/places/nearby", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"total_results":4,"page":1,"per_page":4,"results":{"standard":[
+			{"id":1,"name":"Inside","admin_level":10,"geometry_geojson":%s},
+			{"id":2,"name":"Missing"},
+			{"id":3,"name":"Point","geometry_geojson":{"type":"Point","coordinates":[10.5,50.5]}},
+			{"id":4,"name":"Empty"}
+		],"community":[]}}`, squarePolygon)
+	})
+	mux.HandleFunc("/v1/places/", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/v1/places/2":
+			fmt.Fprint(w, `{"total_results":0,"page":1,"per_page":1,"results":[]}`)
+		case "/v1/places/4":
+			fmt.Fprint(w, `{"total_results":1,"page":1,"per_page":1,"results":[{"id":4,"name":"Empty"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	c, server := newTestClient(t, mux)
+	defer server.Close()
+
+	r := NewPlaceResolver(c)
+	for i := 0; i < 2; i++ {
+		places, err := r.Resolve(Location{Latitude: 50.5, Longitude: 10.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(places) != 1 || places[0].Id != 1 {
+			t.Fatalf("expected only place 1, got %+v", places)
+		}
+	}
+
+	if requests["/v1/places/2"] != 1 || requests["/v1/places/4"] != 1 {
+		t.Errorf("expected places without geometry to be fetched once, got %v", requests)
+	}
+}
+
+func TestResolveFailsOnRequestErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/places/nearby", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_results":1,"page":1,"per_page":1,"results":{"standard":[{"id":2}],"community":[]}}`)
+	})
+	mux.HandleFunc("/v1/places/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	c, server := newTestClient(t, mux)
+	defer server.Close()
+
+	if _, err := NewPlaceResolver(c).Resolve(Location{Latitude: 50.5, Longitude: 10.5}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,179 @@
+package gonaturalist
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type SpeciesCount struct {
+	Count int          `json:"count"`
+	Taxon *SimpleTaxon `json:"taxon"`
+}
+
+type SpeciesCountsPage struct {
+	Paging        *PageHeaders
+	SpeciesCounts []*SpeciesCount
+}
+
+type ObserverCount struct {
+	UserId           int64       `json:"user_id"`
+	ObservationCount int         `json:"observation_count"`
+	SpeciesCount     int         `json:"species_count"`
+	User             *SimpleUser `json:"user"`
+}
+
+type ObserversPage struct {
+	Paging    *PageHeaders
+	Observers []*ObserverCount
+}
+
+type IdentifierCount struct {
+	UserId int64       `json:"user_id"`
+	Count  int         `json:"count"`
+	User   *SimpleUser `json:"user"`
+}
+
+type IdentifiersPage struct {
+	Paging      *PageHeaders
+	Identifiers []*IdentifierCount
+}
+
+type HistogramInterval string
+
+const (
+	YearInterval        HistogramInterval = "year"
+	MonthInterval       HistogramInterval = "month"
+	WeekInterval        HistogramInterval = "week"
+	DayInterval         HistogramInterval = "day"
+	HourInterval        HistogramInterval = "hour"
+	MonthOfYearInterval HistogramInterval = "month_of_year"
+	WeekOfYearInterval  HistogramInterval = "week_of_year"
+)
+
+type HistogramBucket struct {
+	Key   string
+	Count int
+}
+
+type Histogram struct {
+	Interval HistogramInterval
+	Buckets  []*HistogramBucket
+}
+
+func observationsApiValues(opt *GetObservationsOpt) url.Values {
+	if opt == nil {
+		return url.Values{}
+	}
+	v := opt.values()
+	if opt.HasGeo != nil {
+		v.Del("has[]")
+		v.Set("geo", strconv.FormatBool(*opt.HasGeo))
+	}
+	if len(opt.PhotoLicense) > 0 {
+		v.Del("photo_license")
+		v.Del("photo_license[]")
+		codes := make([]string, len(opt.PhotoLicense))
+		for i, l := range opt.PhotoLicense {
+			codes[i] = string(l)
+		}
+		v.Set("photo_license", strings.Join(codes, ","))
+	}
+	return v
+}
+
+func (c *Client) buildObservationsApiUrl(path string, v url.Values) string {
+	u := c.buildApiUrl(path)
+	if params := v.Encode(); params != "" {
+		u += "?" + params
+	}
+	return u
+}
+
+func (c *Client) GetSpeciesCounts(opt *GetObservationsOpt) (*SpeciesCountsPage, error) {
+	var result []*SpeciesCount
+
+	u := c.buildObservationsApiUrl("/observations/species_counts", observationsApiValues(opt))
+	p, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting species counts: %v", err)
+	}
+
+	return &SpeciesCountsPage{
+		SpeciesCounts: result,
+		Paging:        p,
+	}, nil
+}
+
+func (c *Client) GetObservers(opt *GetObservationsOpt) (*ObserversPage, error) {
+	var result []*ObserverCount
+
+	u := c.buildObservationsApiUrl("/observations/observers", observationsApiValues(opt))
+	p, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting observers: %v", err)
+	}
+
+	return &ObserversPage{
+		Observers: result,
+		Paging:    p,
+	}, nil
+}
+
+func (c *Client) GetIdentifiers(opt *GetObservationsOpt) (*IdentifiersPage, error) {
+	var result []*IdentifierCount
+
+	u := c.buildObservationsApiUrl("/observations/identifiers", observationsApiValues(opt))
+	p, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting identifiers: %v", err)
+	}
+
+	return &IdentifiersPage{
+		Identifiers: result,
+		Paging:      p,
+	}, nil
+}
+
+// GetObservationsHistogram counts observations by observed date, bucketed by
+// interval. Buckets are ordered by date, or numerically for the *_of_year
+// intervals.
+func (c *Client) GetObservationsHistogram(interval HistogramInterval, opt *GetObservationsOpt) (*Histogram, error) {
+	var result map[HistogramInterval]map[string]int
+
+	v := observationsApiValues(opt)
+	v.Del("page")
+	v.Del("per_page")
+	v.Set("interval", string(interval))
+	v.Set("date_field", "observed")
+
+	u := c.buildObservationsApiUrl("/observations/histogram", v)
+	_, err := c.getApi(u, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting histogram: %v", err)
+	}
+
+	buckets := make([]*HistogramBucket, 0, len(result[interval]))
+	for key, count := range result[interval] {
+		buckets = append(buckets, &HistogramBucket{
+			Key:   key,
+			Count: count,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		a, errA := strconv.Atoi(buckets[i].Key)
+		b, errB := strconv.Atoi(buckets[j].Key)
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return buckets[i].Key < buckets[j].Key
+	})
+
+	return &Histogram{
+		Interval: interval,
+		Buckets:  buckets,
+	}, nil
+}
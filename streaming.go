@@ -0,0 +1,87 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/context"
+)
+
+// decodeArray reads a JSON array one element at a time, calling each with
+// the decoder positioned at the next element.
+func decodeArray(r io.Reader, each func(d *json.Decoder) error) error {
+	d := json.NewDecoder(r)
+
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("Expected array, got %v", token)
+	}
+
+	for d.More() {
+		if err := each(d); err != nil {
+			return err
+		}
+	}
+
+	_, err = d.Token()
+	return err
+}
+
+// StreamObservations fetches the same page as GetObservations but decodes
+// the response one observation at a time, calling fn for each instead of
+// building the whole page in memory. An error from fn stops the stream and
+// is returned. With a cache set, cacheable pages are still read fully so
+// they can be stored. Cancelling ctx aborts the request.
+func (c *Client) StreamObservations(ctx context.Context, opt *GetObservationsOpt, fn func(o *SimpleObservation) error) (*PageHeaders, error) {
+	queries, err := observationQueries(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *PageHeaders
+	for _, query := range queries {
+		var stopped error
+		p, err := c.getWith(ctx, c.observationsUrl(query), func(r io.Reader) error {
+			return decodeArray(r, func(d *json.Decoder) error {
+				o := &SimpleObservation{}
+				if err := d.Decode(o); err != nil {
+					return err
+				}
+				if err := fn(o); err != nil {
+					stopped = err
+					return err
+				}
+				return nil
+			})
+		})
+		if stopped != nil {
+			return nil, stopped
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error getting observations: %v", err)
+		}
+
+		merged = mergePaging(merged, p)
+	}
+
+	return merged, nil
+}
+
+// StreamObservationsTo sends each observation of the page on ch as it's
+// decoded. The channel is not closed, so several pages can be streamed
+// into the same one. If ctx is done while waiting on a send, the stream
+// stops and ctx's error is returned.
+func (c *Client) StreamObservationsTo(ctx context.Context, opt *GetObservationsOpt, ch chan<- *SimpleObservation) (*PageHeaders, error) {
+	return c.StreamObservations(ctx, opt, func(o *SimpleObservation) error {
+		select {
+		case ch <- o:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
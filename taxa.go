@@ -0,0 +1,21 @@
+package gonaturalist
+
+type CommonName struct {
+	Id      int64  `json:"id"`
+	Name    string `json:"name"`
+	Lexicon string `json:"lexicon"`
+}
+
+type SimpleTaxon struct {
+	Id                  int32       `json:"id"`
+	Name                string      `json:"name"`
+	Rank                string      `json:"rank"`
+	RankLevel           float64     `json:"rank_level"`
+	Ancestry            string      `json:"ancestry"`
+	IconicTaxonId       int32       `json:"iconic_taxon_id"`
+	IconicTaxonName     string      `json:"iconic_taxon_name"`
+	IsActive            bool        `json:"is_active"`
+	ObservationsCount   int         `json:"observations_count"`
+	CommonName          *CommonName `json:"common_name"`
+	PreferredCommonName string      `json:"preferred_common_name"`
+}
@@ -0,0 +1,141 @@
+package gonaturalist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenStore persists a token between runs. Load returns a nil token and
+// no error if nothing has been saved yet.
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(token *oauth2.Token) error
+}
+
+type MemoryTokenStore struct {
+	lock  sync.Mutex
+	token *oauth2.Token
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) Load() (*oauth2.Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token == nil {
+		return nil, nil
+	}
+	copy := *s.token
+	return &copy, nil
+}
+
+func (s *MemoryTokenStore) Save(token *oauth2.Token) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	copy := *token
+	s.token = &copy
+	return nil
+}
+
+// FileTokenStore keeps the token as JSON in a file only readable by the
+// current user.
+type FileTokenStore struct {
+	Path string
+	lock sync.Mutex
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{
+		Path: path,
+	}
+}
+
+func (s *FileTokenStore) Load() (*oauth2.Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("Decoding token %s: %v", s.Path, err)
+	}
+	return &token, nil
+}
+
+func (s *FileTokenStore) Save(token *oauth2.Token) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+
+	// Write alongside and rename so a crash never leaves a partial token.
+	f, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.Path)
+}
+
+// storingTokenSource saves tokens whenever the underlying source refreshes.
+type storingTokenSource struct {
+	lock   sync.Mutex
+	source oauth2.TokenSource
+	store  TokenStore
+	last   string
+}
+
+func (s *storingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if token.AccessToken != s.last {
+		if err := s.store.Save(token); err != nil {
+			return nil, fmt.Errorf("Saving token: %v", err)
+		}
+		s.last = token.AccessToken
+	}
+
+	return token, nil
+}
@@ -0,0 +1,184 @@
+package gonaturalist
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonaturalist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config", "token.json")
+	store := NewFileTokenStore(path)
+
+	if token, err := store.Load(); err != nil || token != nil {
+		t.Fatalf("expected no token before saving, got %v, %v", token, err)
+	}
+
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tokens := []*oauth2.Token{
+		{AccessToken: "first", RefreshToken: "refresh", TokenType: "Bearer", Expiry: expiry},
+		{AccessToken: "second", RefreshToken: "refresh", TokenType: "Bearer", Expiry: expiry.Add(time.Hour)},
+	}
+
+	for _, token := range tokens {
+		if err := store.Save(token); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+		}
+
+		// The token is written alongside and renamed, nothing's left behind.
+		entries, err := ioutil.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "token.json" {
+			names := make([]string, len(entries))
+			for i, e := range entries {
+				names[i] = e.Name()
+			}
+			t.Errorf("expected only token.json, got %v", names)
+		}
+
+		loaded, err := NewFileTokenStore(path).Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.AccessToken != token.AccessToken || loaded.RefreshToken != token.RefreshToken || !loaded.Expiry.Equal(token.Expiry) {
+			t.Errorf("expected %+v, got %+v", token, loaded)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Errorf("expected an error for a corrupt token")
+	}
+}
+
+func TestFileTokenStoreKeepsTokenWhenSaveFails(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions aren't enforced")
+	}
+
+	dir, err := ioutil.TempDir("", "gonaturalist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token.json")
+	store := NewFileTokenStore(path)
+	if err := store.Save(&oauth2.Token{AccessToken: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Chmod(dir, 0500)
+	defer os.Chmod(dir, 0700)
+
+	if err := store.Save(&oauth2.Token{AccessToken: "second"}); err == nil {
+		t.Errorf("expected saving to fail")
+	}
+	if token, err := store.Load(); err != nil || token.AccessToken != "first" {
+		t.Errorf("expected the first token to survive, got %v, %v", token, err)
+	}
+}
+
+type sequenceTokenSource struct {
+	tokens []string
+	calls  int
+}
+
+func (s *sequenceTokenSource) Token() (*oauth2.Token, error) {
+	if s.calls >= len(s.tokens) {
+		return nil, errors.New("refresh failed")
+	}
+	token := &oauth2.Token{AccessToken: s.tokens[s.calls]}
+	s.calls++
+	return token, nil
+}
+
+type countingTokenStore struct {
+	MemoryTokenStore
+	saved []string
+	err   error
+}
+
+func (s *countingTokenStore) Save(token *oauth2.Token) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, token.AccessToken)
+	return s.MemoryTokenStore.Save(token)
+}
+
+func TestStoringTokenSourceSavesRefreshes(t *testing.T) {
+	store := &countingTokenStore{}
+	source := &storingTokenSource{
+		source: &sequenceTokenSource{tokens: []string{"initial", "initial", "refreshed", "refreshed", "again"}},
+		store:  store,
+		last:   "initial",
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := source.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := source.Token(); err == nil {
+		t.Errorf("expected the source's error")
+	}
+
+	if len(store.saved) != 2 || store.saved[0] != "refreshed" || store.saved[1] != "again" {
+		t.Errorf("expected only refreshed tokens to be saved, got %v", store.saved)
+	}
+
+	failing := &storingTokenSource{
+		source: &sequenceTokenSource{tokens: []string{"refreshed"}},
+		store:  &countingTokenStore{err: errors.New("disk full")},
+		last:   "initial",
+	}
+	if _, err := failing.Token(); err == nil {
+		t.Errorf("expected the store's error")
+	}
+}
+
+func TestNewClientWithStoreSavesInitialToken(t *testing.T) {
+	store := &countingTokenStore{}
+	a := NewAuthenticatorAtCustomRoots("id", "secret", "", "http://127.0.0.1:1", "http://127.0.0.1:1/v1")
+
+	if _, err := a.NewClientWithStore(&oauth2.Token{AccessToken: "initial"}, store, &NoopCallbacks{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.saved) != 1 || store.saved[0] != "initial" {
+		t.Errorf("expected the initial token to be saved, got %v", store.saved)
+	}
+
+	c, err := a.NewClientFromStore(store, &NoopCallbacks{})
+	if err != nil || c == nil {
+		t.Errorf("expected a client from the saved token, got %v", err)
+	}
+
+	if _, err := a.NewClientFromStore(NewMemoryTokenStore(), &NoopCallbacks{}); err == nil {
+		t.Errorf("expected an error from an empty store")
+	}
+}
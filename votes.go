@@ -0,0 +1,118 @@
+package gonaturalist
+
+import (
+	"net/http"
+	"time"
+)
+
+type QualityGrade string
+
+const (
+	CasualGrade   QualityGrade = "casual"
+	NeedsIdGrade  QualityGrade = "needs_id"
+	ResearchGrade QualityGrade = "research"
+)
+
+type QualityMetricName string
+
+const (
+	WildMetric     QualityMetricName = "wild"
+	LocationMetric QualityMetricName = "location"
+	DateMetric     QualityMetricName = "date"
+	EvidenceMetric QualityMetricName = "evidence"
+	RecentMetric   QualityMetricName = "recent"
+	SubjectMetric  QualityMetricName = "subject"
+)
+
+type QualityMetric struct {
+	Id        int64             `json:"id"`
+	UserId    int64             `json:"user_id"`
+	Metric    QualityMetricName `json:"metric"`
+	Agree     bool              `json:"agree"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type Vote struct {
+	Id        int64     `json:"id"`
+	UserId    int64     `json:"user_id"`
+	VoteFlag  bool      `json:"vote_flag"`
+	VoteScope string    `json:"vote_scope"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const needsIdVoteScope = "needs_id"
+
+func (c *Client) FaveObservation(id int64) error {
+	u := c.buildUrl("/votes/vote/observation/%d.json", id)
+	if err := c.executeEmpty("POST", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+func (c *Client) UnfaveObservation(id int64) error {
+	u := c.buildUrl("/votes/unvote/observation/%d.json", id)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+// VoteQualityMetric agrees or disagrees with one of the data quality
+// assessment questions, e.g. whether the organism is wild.
+func (c *Client) VoteQualityMetric(id int64, metric QualityMetricName, agree bool) error {
+	u := c.buildUrl("/observations/%d/quality/%s.json?agree=%t", id, metric, agree)
+	if err := c.executeEmpty("POST", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+func (c *Client) DeleteQualityMetricVote(id int64, metric QualityMetricName) error {
+	u := c.buildUrl("/observations/%d/quality/%s.json", id, metric)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+// VoteNeedsId answers "can the community ID still be confirmed or
+// improved?", yes keeps the observation in the needs ID pool.
+func (c *Client) VoteNeedsId(id int64, needsId bool) error {
+	vote := "yes"
+	if !needsId {
+		vote = "no"
+	}
+	u := c.buildUrl("/votes/vote/observation/%d.json?scope=%s&vote=%s", id, needsIdVoteScope, vote)
+	if err := c.executeEmpty("POST", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}
+
+func (c *Client) DeleteNeedsIdVote(id int64) error {
+	u := c.buildUrl("/votes/unvote/observation/%d.json?scope=%s", id, needsIdVoteScope)
+	if err := c.executeEmpty("DELETE", u, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.invalidateObservations()
+
+	return nil
+}